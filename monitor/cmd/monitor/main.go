@@ -6,7 +6,11 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ag-botkit/monitor/internal/aggregator"
 	"github.com/ag-botkit/monitor/internal/server"
 	"github.com/ag-botkit/monitor/internal/storage"
 )
@@ -15,6 +19,16 @@ func main() {
 	addr := flag.String("addr", "localhost:8080", "HTTP server address")
 	capacity := flag.Int("capacity", 10000, "Metric storage capacity per metric")
 	webDir := flag.String("web", "", "Web directory path (defaults to ./web or ../../web)")
+
+	aggEnabled := flag.Bool("aggregate", true, "Enable tumbling-window rollups (count/sum/min/max/mean/percentiles)")
+	aggWindows := flag.String("agg-windows", "10s,1m,5m", "Comma-separated tumbling window sizes, e.g. 10s,1m,5m")
+	aggPercentiles := flag.String("agg-percentiles", "50,90,99", "Comma-separated percentiles to roll up, e.g. 50,90,99")
+	aggGrace := flag.Duration("agg-grace", 5*time.Second, "How far before a window's start a late point may still land in it")
+	aggDelay := flag.Duration("agg-delay", 2*time.Second, "How long past a window's end to wait before flushing it")
+
+	dataDir := flag.String("data-dir", "", "Directory for WAL persistence (empty disables durability, memory-only)")
+	retention := flag.Duration("retention", 24*time.Hour, "How long to retain WAL segments before compacting them")
+
 	flag.Parse()
 
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
@@ -46,11 +60,75 @@ func main() {
 	log.Printf("Serving static files from: %s", absPath)
 
 	// Create metric store
-	store := storage.NewMetricStore(*capacity)
+	var store *storage.MetricStore
+	if *dataDir != "" {
+		var err error
+		store, err = storage.NewMetricStoreWithWAL(*capacity, storage.WALConfig{
+			DataDir:   *dataDir,
+			Retention: *retention,
+		})
+		if err != nil {
+			log.Fatalf("Failed to open WAL at %s: %v", *dataDir, err)
+		}
+	} else {
+		store = storage.NewMetricStore(*capacity)
+	}
 
 	// Create and start server
 	srv := server.NewServer(*addr, store)
 	srv.SetupRoutes(http.Dir(webPath))
 
+	if *aggEnabled {
+		cfg := aggregator.Config{
+			Windows:     parseDurations(*aggWindows),
+			Percentiles: parsePercentiles(*aggPercentiles),
+			Grace:       *aggGrace,
+			Delay:       *aggDelay,
+		}
+		agg := aggregator.New(store, srv.Hub(), cfg)
+		srv.Hub().SetAggregator(agg)
+		go agg.Run(make(chan struct{}))
+		log.Printf("Aggregator enabled: windows=%v percentiles=%v grace=%s delay=%s",
+			cfg.Windows, cfg.Percentiles, cfg.Grace, cfg.Delay)
+	}
+
 	log.Fatal(srv.Start())
 }
+
+// parseDurations parses a comma-separated list of Go durations, skipping
+// (and logging) any entry that fails to parse.
+func parseDurations(raw string) []time.Duration {
+	var out []time.Duration
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil {
+			log.Printf("Ignoring invalid -agg-windows entry %q: %v", part, err)
+			continue
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// parsePercentiles parses a comma-separated list of percentiles given as
+// 0-100 values (e.g. "50,90,99") into 0..1 fractions.
+func parsePercentiles(raw string) []float64 {
+	var out []float64
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		p, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			log.Printf("Ignoring invalid -agg-percentiles entry %q: %v", part, err)
+			continue
+		}
+		out = append(out, p/100)
+	}
+	return out
+}
@@ -0,0 +1,262 @@
+// Package aggregator rolls up raw metric points into tumbling-window
+// summaries (count, sum, min, max, mean, percentiles) and feeds the results
+// back into the store under derived metric names, e.g. cpu.usage.p99.1m.
+package aggregator
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ag-botkit/monitor/internal/storage"
+)
+
+// Config controls which tumbling windows are maintained, which percentiles
+// are computed, and how late-arriving points are handled.
+type Config struct {
+	// Windows are the tumbling window sizes to roll up, e.g. 10s, 1m, 5m.
+	Windows []time.Duration
+	// Percentiles are reported as 0..1 fractions (0.99 for p99).
+	Percentiles []float64
+	// Grace is how far before a window's start a point may still land in it.
+	Grace time.Duration
+	// Delay is how long past a window's end to wait before flushing it, to
+	// absorb points that arrive slightly out of order.
+	Delay time.Duration
+}
+
+// DefaultConfig matches the windows/percentiles most dashboards expect.
+func DefaultConfig() Config {
+	return Config{
+		Windows:     []time.Duration{10 * time.Second, time.Minute, 5 * time.Minute},
+		Percentiles: []float64{0.5, 0.9, 0.99},
+		Grace:       5 * time.Second,
+		Delay:       2 * time.Second,
+	}
+}
+
+// Broadcaster is the subset of server.Hub the aggregator needs, so this
+// package doesn't have to import server.
+type Broadcaster interface {
+	BroadcastMetric(*storage.MetricPoint)
+}
+
+// window accumulates one tumbling period's worth of observations for a
+// single series.
+type window struct {
+	start  int64 // ms, inclusive
+	sketch *sketch
+}
+
+func newWindow(startMs int64) *window {
+	return &window{start: startMs, sketch: newSketch()}
+}
+
+// series is the per-metric-per-label-set state: one window per configured
+// duration.
+type series struct {
+	metricName string
+	labels     map[string]string
+	windows    map[time.Duration]*window
+}
+
+// Aggregator wraps a storage.MetricStore with periodic rollups.
+type Aggregator struct {
+	store *storage.MetricStore
+	bcast Broadcaster
+	cfg   Config
+
+	mu     sync.Mutex
+	series map[string]*series // key: seriesKey(metric, labels)
+
+	droppedLate int64
+}
+
+// New creates an Aggregator. Call Observe for every raw point and run Run in
+// its own goroutine to flush windows as they close.
+func New(store *storage.MetricStore, bcast Broadcaster, cfg Config) *Aggregator {
+	return &Aggregator{
+		store:  store,
+		bcast:  bcast,
+		cfg:    cfg,
+		series: make(map[string]*series),
+	}
+}
+
+// Observe feeds one raw point into every configured tumbling window for its
+// series. Points that fall outside [windowStart-Grace, windowEnd+Delay] are
+// dropped and counted rather than corrupting an already-flushed window.
+func (a *Aggregator) Observe(p storage.MetricPoint) {
+	key := seriesKey(p.MetricName, p.Labels)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s, ok := a.series[key]
+	if !ok {
+		s = &series{metricName: p.MetricName, labels: p.Labels, windows: make(map[time.Duration]*window)}
+		a.series[key] = s
+	}
+
+	for _, d := range a.cfg.Windows {
+		w, ok := s.windows[d]
+		if !ok {
+			w = newWindow(periodStart(p.Timestamp, d))
+			s.windows[d] = w
+		}
+
+		periodEnd := w.start + d.Milliseconds()
+		if p.Timestamp < w.start-a.cfg.Grace.Milliseconds() || p.Timestamp > periodEnd+a.cfg.Delay.Milliseconds() {
+			a.droppedLate++
+			log.Printf("debug: aggregator dropping late point for %s window=%s ts=%d period=[%d,%d]",
+				p.MetricName, d, p.Timestamp, w.start, periodEnd)
+			continue
+		}
+
+		w.sketch.add(p.Value)
+	}
+}
+
+// Run flushes closed windows until stop is closed. It should be started in
+// its own goroutine.
+func (a *Aggregator) Run(stop <-chan struct{}) {
+	interval := a.flushInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			a.flush(time.Now().UnixMilli())
+		}
+	}
+}
+
+// flushInterval picks a tick period fine enough to flush the shortest window
+// promptly without busy-looping for long-window-only configs.
+func (a *Aggregator) flushInterval() time.Duration {
+	shortest := time.Minute
+	for _, d := range a.cfg.Windows {
+		if d < shortest {
+			shortest = d
+		}
+	}
+	interval := shortest / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	return interval
+}
+
+// flush emits and rotates any window whose [start, end+Delay] has fully
+// elapsed as of nowMs.
+func (a *Aggregator) flush(nowMs int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, s := range a.series {
+		for d, w := range s.windows {
+			periodEnd := w.start + d.Milliseconds()
+			if nowMs < periodEnd+a.cfg.Delay.Milliseconds() {
+				continue
+			}
+			if w.sketch.count > 0 {
+				a.emit(s, d, w)
+			}
+			s.windows[d] = newWindow(periodStart(nowMs, d))
+		}
+	}
+}
+
+// emit appends and broadcasts one rollup point per statistic for a closed
+// window.
+func (a *Aggregator) emit(s *series, d time.Duration, w *window) {
+	suffix := windowSuffix(d)
+	sk := w.sketch
+
+	stats := map[string]float64{
+		"count": float64(sk.count),
+		"sum":   sk.sum,
+		"min":   sk.min,
+		"max":   sk.max,
+		"mean":  sk.mean(),
+	}
+	for _, q := range a.cfg.Percentiles {
+		stats[percentileName(q)] = sk.quantile(q)
+	}
+
+	for stat, value := range stats {
+		point := storage.MetricPoint{
+			Timestamp:  w.start + d.Milliseconds(),
+			MetricType: "gauge",
+			MetricName: fmt.Sprintf("%s.%s.%s", s.metricName, stat, suffix),
+			Value:      value,
+			Labels:     s.labels,
+		}
+		a.store.Append(point)
+		a.bcast.BroadcastMetric(&point)
+	}
+}
+
+// DroppedLate returns the number of points dropped for arriving outside
+// their window's grace/delay bounds.
+func (a *Aggregator) DroppedLate() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.droppedLate
+}
+
+// periodStart aligns ts down to the start of its tumbling window.
+func periodStart(tsMs int64, d time.Duration) int64 {
+	size := d.Milliseconds()
+	if size <= 0 {
+		return tsMs
+	}
+	return tsMs - (tsMs % size)
+}
+
+// windowSuffix renders a duration the way dashboards expect in derived
+// metric names: "10s", "1m", "5m".
+func windowSuffix(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
+
+// percentileName renders 0.99 as "p99", 0.5 as "p50", etc.
+func percentileName(q float64) string {
+	return fmt.Sprintf("p%d", int(q*100))
+}
+
+// seriesKey uniquely identifies a metric+label-set combination.
+func seriesKey(metricName string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return metricName
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(metricName)
+	for _, k := range keys {
+		b.WriteByte('\x1f')
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(labels[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ag-botkit/monitor/internal/storage"
+)
+
+type fakeBroadcaster struct {
+	points []*storage.MetricPoint
+}
+
+func (f *fakeBroadcaster) BroadcastMetric(p *storage.MetricPoint) {
+	f.points = append(f.points, p)
+}
+
+func TestPeriodStart(t *testing.T) {
+	got := periodStart(12345, 10*time.Second)
+	if got != 10000 {
+		t.Errorf("expected 10000, got %d", got)
+	}
+}
+
+func TestWindowSuffix(t *testing.T) {
+	cases := map[time.Duration]string{
+		10 * time.Second: "10s",
+		time.Minute:      "1m",
+		5 * time.Minute:  "5m",
+		time.Hour:        "1h",
+	}
+	for d, want := range cases {
+		if got := windowSuffix(d); got != want {
+			t.Errorf("windowSuffix(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestPercentileName(t *testing.T) {
+	if got := percentileName(0.99); got != "p99" {
+		t.Errorf("expected p99, got %q", got)
+	}
+	if got := percentileName(0.5); got != "p50" {
+		t.Errorf("expected p50, got %q", got)
+	}
+}
+
+func TestSketchMeanAndQuantile(t *testing.T) {
+	sk := newSketch()
+	for _, v := range []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10} {
+		sk.add(v)
+	}
+
+	if sk.mean() != 5.5 {
+		t.Errorf("expected mean 5.5, got %f", sk.mean())
+	}
+	if sk.min != 1 || sk.max != 10 {
+		t.Errorf("expected min=1 max=10, got min=%f max=%f", sk.min, sk.max)
+	}
+	if got := sk.quantile(1.0); got != 10 {
+		t.Errorf("expected p100 = 10, got %f", got)
+	}
+}
+
+func TestAggregator_EmitsRollupOnFlush(t *testing.T) {
+	store := storage.NewMetricStore(100)
+	bcast := &fakeBroadcaster{}
+
+	cfg := Config{
+		Windows:     []time.Duration{10 * time.Second},
+		Percentiles: []float64{0.9},
+		Grace:       time.Second,
+		Delay:       time.Second,
+	}
+	agg := New(store, bcast, cfg)
+
+	base := int64(1_000_000)
+	agg.Observe(storage.MetricPoint{Timestamp: base, MetricName: "cpu.usage", Value: 10})
+	agg.Observe(storage.MetricPoint{Timestamp: base + 1000, MetricName: "cpu.usage", Value: 20})
+
+	// Not yet past periodEnd+Delay: nothing should flush. Observe() never
+	// appends raw points to the store itself, so until the first flush
+	// happens the store must be completely empty.
+	agg.flush(base + 5000)
+	if len(store.GetAllMetrics()) != 0 {
+		t.Fatalf("expected no rollups yet, store has %v", store.GetAllMetrics())
+	}
+
+	// Past periodEnd (base - base%10000 + 10000) + Delay(1s).
+	agg.flush(base - base%10000 + 10000 + 1000 + 1)
+
+	meanPoints := store.GetLast("cpu.usage.mean.10s", 1)
+	if len(meanPoints) != 1 {
+		t.Fatalf("expected a mean rollup point, got %d", len(meanPoints))
+	}
+	if meanPoints[0].Value != 15 {
+		t.Errorf("expected mean 15, got %f", meanPoints[0].Value)
+	}
+}
+
+func TestAggregator_DropsLatePoints(t *testing.T) {
+	store := storage.NewMetricStore(100)
+	bcast := &fakeBroadcaster{}
+
+	cfg := Config{
+		Windows:     []time.Duration{10 * time.Second},
+		Percentiles: nil,
+		Grace:       0,
+		Delay:       0,
+	}
+	agg := New(store, bcast, cfg)
+
+	agg.Observe(storage.MetricPoint{Timestamp: 0, MetricName: "late.metric", Value: 1})
+	// Far outside the first window's grace/delay bounds.
+	agg.Observe(storage.MetricPoint{Timestamp: 1_000_000, MetricName: "late.metric", Value: 2})
+
+	if agg.DroppedLate() != 1 {
+		t.Errorf("expected 1 dropped late point, got %d", agg.DroppedLate())
+	}
+}
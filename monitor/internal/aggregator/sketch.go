@@ -0,0 +1,90 @@
+package aggregator
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// maxSketchSamples bounds memory for a single window's quantile sketch: the
+// sample slice never grows past this length, however many observations are
+// added. Once full, new observations replace an existing sample at random
+// (reservoir sampling), so every observation has an equal chance of being
+// represented regardless of how many more arrive after it.
+const maxSketchSamples = 2048
+
+// sketch is a simplified streaming quantile estimator: a bounded reservoir
+// of samples, sorted lazily at query time. It trades the precision of a true
+// t-digest/HDR histogram for simplicity, which is fine at the cardinalities
+// ag-botkit targets (sketches are per metric, per label-set, per window).
+type sketch struct {
+	samples  []float64
+	inserts  int64
+	count    int64
+	sum      float64
+	min, max float64
+}
+
+func newSketch() *sketch {
+	return &sketch{}
+}
+
+// add records one observation. The reservoir fills up to maxSketchSamples,
+// then each further observation replaces a uniformly random existing slot
+// with probability maxSketchSamples/inserts, so len(s.samples) never grows
+// past the cap and every observation seen so far has equal weight.
+func (s *sketch) add(v float64) {
+	if s.count == 0 {
+		s.min, s.max = v, v
+	} else {
+		if v < s.min {
+			s.min = v
+		}
+		if v > s.max {
+			s.max = v
+		}
+	}
+
+	s.count++
+	s.sum += v
+	s.inserts++
+
+	if len(s.samples) < maxSketchSamples {
+		s.samples = append(s.samples, v)
+		return
+	}
+
+	if idx := rand.Int63n(s.inserts); idx < maxSketchSamples {
+		s.samples[idx] = v
+	}
+}
+
+// mean returns the arithmetic mean of all observations (exact, independent
+// of reservoir sampling, since sum/count are tracked directly).
+func (s *sketch) mean() float64 {
+	if s.count == 0 {
+		return 0
+	}
+	return s.sum / float64(s.count)
+}
+
+// quantile returns the q-th quantile (0 <= q <= 1) of the sampled reservoir.
+// Samples aren't kept sorted on insert (reservoir replacement is by random
+// index), so this sorts a copy on demand.
+func (s *sketch) quantile(q float64) float64 {
+	if len(s.samples) == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, len(s.samples))
+	copy(sorted, s.samples)
+	sort.Float64s(sorted)
+
+	idx := int(q * float64(len(sorted)-1))
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
@@ -0,0 +1,48 @@
+package query
+
+import "time"
+
+// Expr is a parsed query AST node.
+type Expr interface{ isExpr() }
+
+// VectorSelector selects raw points for one metric, optionally narrowed by
+// label matchers and (inside a range-vector context like rate(...)) a
+// trailing [duration] range.
+type VectorSelector struct {
+	Metric   string
+	Matchers []*LabelMatcher
+	Range    time.Duration
+}
+
+// LabelMatcher is one `name<op>"value"` constraint inside a `{...}` selector.
+type LabelMatcher struct {
+	Name   string
+	Value  string
+	Regex  bool
+	Negate bool
+}
+
+// AggrExpr is an aggregation over a vector, e.g. `sum by(host) (...)`.
+type AggrExpr struct {
+	Op   string // sum, avg, max, min
+	By   []string
+	Expr Expr
+}
+
+// CallExpr is a function call over a range vector, e.g. `rate(metric[1m])`.
+type CallExpr struct {
+	Func string
+	Args []Expr
+}
+
+// BinaryExpr is arithmetic between two vectors, matched by identical label
+// sets ignoring __name__.
+type BinaryExpr struct {
+	Op       string // +, -, *, /
+	LHS, RHS Expr
+}
+
+func (*VectorSelector) isExpr() {}
+func (*AggrExpr) isExpr()       {}
+func (*CallExpr) isExpr()       {}
+func (*BinaryExpr) isExpr()     {}
@@ -0,0 +1,449 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ag-botkit/monitor/internal/storage"
+)
+
+// Store is the subset of storage.MetricStore the evaluator needs.
+type Store interface {
+	GetRange(metricName string, startMs, endMs int64) []storage.MetricPoint
+	GetAllMetrics() []string
+}
+
+// staleness bounds how far back an instant lookup may reach for a sample,
+// matching Prometheus's "last value wins within 5m" default.
+const staleness = 5 * time.Minute
+
+// maxRangeSamples bounds how many step-aligned timestamps a single
+// EvalRange call may produce per series, matching the spirit of
+// Prometheus's query.max-samples guard. Without it, a wide start/end paired
+// with a tiny step (query_range is a public, unauthenticated endpoint) could
+// ask stepTimestamps to build an unbounded slice and hang or OOM the process.
+const maxRangeSamples = 11000
+
+// Evaluator walks a parsed Expr against a Store.
+type Evaluator struct {
+	store Store
+}
+
+// NewEvaluator creates an Evaluator backed by store.
+func NewEvaluator(store Store) *Evaluator {
+	return &Evaluator{store: store}
+}
+
+// EvalInstant evaluates expr as of tMs, returning (at most) one sample per series.
+func (e *Evaluator) EvalInstant(expr Expr, tMs int64) ([]Series, error) {
+	return e.eval(expr, tMs, tMs, 0)
+}
+
+// EvalRange evaluates expr at every step-aligned timestamp in [startMs, endMs].
+func (e *Evaluator) EvalRange(expr Expr, startMs, endMs, stepMs int64) ([]Series, error) {
+	if stepMs <= 0 {
+		return nil, fmt.Errorf("step must be positive")
+	}
+	if startMs > endMs {
+		return nil, fmt.Errorf("start must not be after end")
+	}
+	if samples := (endMs-startMs)/stepMs + 1; samples > maxRangeSamples {
+		return nil, fmt.Errorf("query would produce %d samples, exceeding the maximum of %d; widen step or narrow the time range", samples, maxRangeSamples)
+	}
+	return e.eval(expr, startMs, endMs, stepMs)
+}
+
+// eval is the shared instant/range path. stepMs == 0 means "evaluate once,
+// at endMs" (the instant case); startMs is otherwise the first step.
+func (e *Evaluator) eval(expr Expr, startMs, endMs, stepMs int64) ([]Series, error) {
+	switch ex := expr.(type) {
+	case *VectorSelector:
+		return e.evalSelector(ex, startMs, endMs, stepMs)
+	case *CallExpr:
+		return e.evalCall(ex, startMs, endMs, stepMs)
+	case *AggrExpr:
+		return e.evalAggr(ex, startMs, endMs, stepMs)
+	case *BinaryExpr:
+		return e.evalBinary(ex, startMs, endMs, stepMs)
+	default:
+		return nil, fmt.Errorf("unsupported expression type %T", expr)
+	}
+}
+
+func (e *Evaluator) evalSelector(sel *VectorSelector, startMs, endMs, stepMs int64) ([]Series, error) {
+	byKey, labelsByKey, err := e.gatherSeries(sel, startMs-staleness.Milliseconds(), endMs)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := stepTimestamps(startMs, endMs, stepMs)
+
+	result := make([]Series, 0, len(byKey))
+	for key, pts := range byKey {
+		sort.Slice(pts, func(i, j int) bool { return pts[i].Timestamp < pts[j].Timestamp })
+
+		s := Series{Labels: withName(labelsByKey[key], sel.Metric)}
+		for _, ts := range steps {
+			v, ok := lastValueAtOrBefore(pts, ts, staleness.Milliseconds())
+			if !ok {
+				continue
+			}
+			s.Samples = append(s.Samples, Sample{TimestampMs: ts, Value: v})
+		}
+		if len(s.Samples) > 0 {
+			result = append(result, s)
+		}
+	}
+
+	return result, nil
+}
+
+func (e *Evaluator) evalCall(ex *CallExpr, startMs, endMs, stepMs int64) ([]Series, error) {
+	if ex.Func != "rate" {
+		return nil, fmt.Errorf("unsupported function %q", ex.Func)
+	}
+	if len(ex.Args) != 1 {
+		return nil, fmt.Errorf("rate() takes exactly one argument")
+	}
+	sel, ok := ex.Args[0].(*VectorSelector)
+	if !ok || sel.Range <= 0 {
+		return nil, fmt.Errorf("rate() requires a range vector selector, e.g. metric[1m]")
+	}
+
+	rangeMs := sel.Range.Milliseconds()
+	byKey, labelsByKey, err := e.gatherSeries(sel, startMs-rangeMs, endMs)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := stepTimestamps(startMs, endMs, stepMs)
+
+	result := make([]Series, 0, len(byKey))
+	for key, pts := range byKey {
+		sort.Slice(pts, func(i, j int) bool { return pts[i].Timestamp < pts[j].Timestamp })
+
+		s := Series{Labels: withName(labelsByKey[key], sel.Metric)}
+		for _, ts := range steps {
+			v, ok := rateOverWindow(pts, ts-rangeMs, ts)
+			if !ok {
+				continue
+			}
+			s.Samples = append(s.Samples, Sample{TimestampMs: ts, Value: v})
+		}
+		if len(s.Samples) > 0 {
+			result = append(result, s)
+		}
+	}
+
+	return result, nil
+}
+
+func (e *Evaluator) evalAggr(ex *AggrExpr, startMs, endMs, stepMs int64) ([]Series, error) {
+	inner, err := e.eval(ex.Expr, startMs, endMs, stepMs)
+	if err != nil {
+		return nil, err
+	}
+
+	type group struct {
+		labels map[string]string
+		values map[int64][]float64
+	}
+	groups := make(map[string]*group)
+
+	for _, s := range inner {
+		groupLabels := projectLabels(s.Labels, ex.By)
+		key := labelKey(groupLabels)
+
+		g, ok := groups[key]
+		if !ok {
+			g = &group{labels: groupLabels, values: make(map[int64][]float64)}
+			groups[key] = g
+		}
+		for _, sample := range s.Samples {
+			g.values[sample.TimestampMs] = append(g.values[sample.TimestampMs], sample.Value)
+		}
+	}
+
+	result := make([]Series, 0, len(groups))
+	for _, g := range groups {
+		timestamps := make([]int64, 0, len(g.values))
+		for ts := range g.values {
+			timestamps = append(timestamps, ts)
+		}
+		sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+		series := Series{Labels: g.labels}
+		for _, ts := range timestamps {
+			series.Samples = append(series.Samples, Sample{TimestampMs: ts, Value: reduce(ex.Op, g.values[ts])})
+		}
+		result = append(result, series)
+	}
+
+	return result, nil
+}
+
+func (e *Evaluator) evalBinary(ex *BinaryExpr, startMs, endMs, stepMs int64) ([]Series, error) {
+	lhs, err := e.eval(ex.LHS, startMs, endMs, stepMs)
+	if err != nil {
+		return nil, err
+	}
+	rhs, err := e.eval(ex.RHS, startMs, endMs, stepMs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Vector matching ignores __name__ (as in PromQL's default "on()"
+	// behavior) since binary ops are typically between different metrics.
+	rhsByKey := make(map[string]Series, len(rhs))
+	for _, s := range rhs {
+		rhsByKey[labelKeyExceptName(s.Labels)] = s
+	}
+
+	var result []Series
+	for _, l := range lhs {
+		r, ok := rhsByKey[labelKeyExceptName(l.Labels)]
+		if !ok {
+			continue
+		}
+
+		rValues := make(map[int64]float64, len(r.Samples))
+		for _, sample := range r.Samples {
+			rValues[sample.TimestampMs] = sample.Value
+		}
+
+		series := Series{Labels: l.Labels}
+		for _, sample := range l.Samples {
+			rv, ok := rValues[sample.TimestampMs]
+			if !ok {
+				continue
+			}
+			v, err := applyBinaryOp(ex.Op, sample.Value, rv)
+			if err != nil {
+				return nil, err
+			}
+			series.Samples = append(series.Samples, Sample{TimestampMs: sample.TimestampMs, Value: v})
+		}
+		if len(series.Samples) > 0 {
+			result = append(result, series)
+		}
+	}
+
+	return result, nil
+}
+
+// gatherSeries fetches raw points for a selector across [startMs, endMs],
+// grouped by label set and filtered by the selector's matchers.
+func (e *Evaluator) gatherSeries(sel *VectorSelector, startMs, endMs int64) (map[string][]storage.MetricPoint, map[string]map[string]string, error) {
+	matchers := make([]*compiledMatcher, 0, len(sel.Matchers))
+	for _, m := range sel.Matchers {
+		cm, err := compileMatcher(m)
+		if err != nil {
+			return nil, nil, err
+		}
+		matchers = append(matchers, cm)
+	}
+
+	points := e.store.GetRange(sel.Metric, startMs, endMs)
+
+	byKey := make(map[string][]storage.MetricPoint)
+	labelsByKey := make(map[string]map[string]string)
+
+	for _, p := range points {
+		if !matchAll(matchers, p.Labels) {
+			continue
+		}
+		key := labelKey(p.Labels)
+		byKey[key] = append(byKey[key], p)
+		labelsByKey[key] = p.Labels
+	}
+
+	return byKey, labelsByKey, nil
+}
+
+type compiledMatcher struct {
+	name   string
+	value  string
+	regex  *regexp.Regexp
+	negate bool
+}
+
+func compileMatcher(m *LabelMatcher) (*compiledMatcher, error) {
+	cm := &compiledMatcher{name: m.Name, value: m.Value, negate: m.Negate}
+	if m.Regex {
+		re, err := regexp.Compile("^(?:" + m.Value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex for label %s: %w", m.Name, err)
+		}
+		cm.regex = re
+	}
+	return cm, nil
+}
+
+func matchAll(matchers []*compiledMatcher, labels map[string]string) bool {
+	for _, m := range matchers {
+		v := labels[m.name]
+		var ok bool
+		if m.regex != nil {
+			ok = m.regex.MatchString(v)
+		} else {
+			ok = v == m.value
+		}
+		if m.negate {
+			ok = !ok
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func lastValueAtOrBefore(pts []storage.MetricPoint, ts, maxAgeMs int64) (float64, bool) {
+	var best *storage.MetricPoint
+	for i := range pts {
+		if pts[i].Timestamp > ts {
+			break
+		}
+		best = &pts[i]
+	}
+	if best == nil || ts-best.Timestamp > maxAgeMs {
+		return 0, false
+	}
+	return best.Value, true
+}
+
+func rateOverWindow(pts []storage.MetricPoint, startMs, endMs int64) (float64, bool) {
+	var first, last *storage.MetricPoint
+	for i := range pts {
+		if pts[i].Timestamp < startMs || pts[i].Timestamp > endMs {
+			continue
+		}
+		if first == nil {
+			first = &pts[i]
+		}
+		last = &pts[i]
+	}
+	if first == nil || last == nil || first == last {
+		return 0, false
+	}
+	seconds := float64(last.Timestamp-first.Timestamp) / 1000
+	if seconds <= 0 {
+		return 0, false
+	}
+	return (last.Value - first.Value) / seconds, true
+}
+
+func stepTimestamps(startMs, endMs, stepMs int64) []int64 {
+	if stepMs <= 0 {
+		return []int64{endMs}
+	}
+	out := make([]int64, 0, (endMs-startMs)/stepMs+1)
+	for ts := startMs; ts <= endMs; ts += stepMs {
+		out = append(out, ts)
+	}
+	return out
+}
+
+func withName(labels map[string]string, name string) map[string]string {
+	out := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		out[k] = v
+	}
+	out["__name__"] = name
+	return out
+}
+
+func projectLabels(labels map[string]string, by []string) map[string]string {
+	if len(by) == 0 {
+		return map[string]string{}
+	}
+	out := make(map[string]string, len(by))
+	for _, k := range by {
+		if v, ok := labels[k]; ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func reduce(op string, values []float64) float64 {
+	switch op {
+	case "sum":
+		var total float64
+		for _, v := range values {
+			total += v
+		}
+		return total
+	case "avg":
+		var total float64
+		for _, v := range values {
+			total += v
+		}
+		return total / float64(len(values))
+	case "max":
+		m := values[0]
+		for _, v := range values[1:] {
+			if v > m {
+				m = v
+			}
+		}
+		return m
+	case "min":
+		m := values[0]
+		for _, v := range values[1:] {
+			if v < m {
+				m = v
+			}
+		}
+		return m
+	default:
+		return 0
+	}
+}
+
+func applyBinaryOp(op string, a, b float64) (float64, error) {
+	switch op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		return a / b, nil
+	default:
+		return 0, fmt.Errorf("unsupported binary operator %q", op)
+	}
+}
+
+func labelKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(labels[k])
+		b.WriteByte('\x1f')
+	}
+	return b.String()
+}
+
+// labelKeyExceptName is labelKey but ignoring __name__, for binary vector
+// matching where the two sides are usually different metrics.
+func labelKeyExceptName(labels map[string]string) string {
+	filtered := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if k != "__name__" {
+			filtered[k] = v
+		}
+	}
+	return labelKey(filtered)
+}
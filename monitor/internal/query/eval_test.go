@@ -0,0 +1,121 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/ag-botkit/monitor/internal/storage"
+)
+
+func TestEval_InstantSelectorWithLabelMatch(t *testing.T) {
+	store := storage.NewMetricStore(100)
+	store.Append(storage.MetricPoint{Timestamp: 1000, MetricName: "cpu.usage", Value: 10, Labels: map[string]string{"host": "web1"}})
+	store.Append(storage.MetricPoint{Timestamp: 1000, MetricName: "cpu.usage", Value: 99, Labels: map[string]string{"host": "web2"}})
+
+	expr, err := Parse(`cpu.usage{host="web1"}`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	series, err := NewEvaluator(store).EvalInstant(expr, 1000)
+	if err != nil {
+		t.Fatalf("eval error: %v", err)
+	}
+	if len(series) != 1 {
+		t.Fatalf("expected 1 series, got %d", len(series))
+	}
+	if len(series[0].Samples) != 1 || series[0].Samples[0].Value != 10 {
+		t.Errorf("unexpected samples: %+v", series[0].Samples)
+	}
+}
+
+func TestEval_SumAggregation(t *testing.T) {
+	store := storage.NewMetricStore(100)
+	store.Append(storage.MetricPoint{Timestamp: 1000, MetricName: "cpu.usage", Value: 10, Labels: map[string]string{"host": "web1"}})
+	store.Append(storage.MetricPoint{Timestamp: 1000, MetricName: "cpu.usage", Value: 20, Labels: map[string]string{"host": "web2"}})
+
+	expr, err := Parse(`sum(cpu.usage)`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	series, err := NewEvaluator(store).EvalInstant(expr, 1000)
+	if err != nil {
+		t.Fatalf("eval error: %v", err)
+	}
+	if len(series) != 1 {
+		t.Fatalf("expected 1 aggregated series, got %d", len(series))
+	}
+	if series[0].Samples[0].Value != 30 {
+		t.Errorf("expected sum 30, got %f", series[0].Samples[0].Value)
+	}
+}
+
+func TestEval_Rate(t *testing.T) {
+	store := storage.NewMetricStore(100)
+	store.Append(storage.MetricPoint{Timestamp: 0, MetricName: "requests.total", Value: 0})
+	store.Append(storage.MetricPoint{Timestamp: 60000, MetricName: "requests.total", Value: 120})
+
+	expr, err := Parse(`rate(requests.total[1m])`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	series, err := NewEvaluator(store).EvalInstant(expr, 60000)
+	if err != nil {
+		t.Fatalf("eval error: %v", err)
+	}
+	if len(series) != 1 {
+		t.Fatalf("expected 1 series, got %d", len(series))
+	}
+	if series[0].Samples[0].Value != 2 {
+		t.Errorf("expected rate 2/s, got %f", series[0].Samples[0].Value)
+	}
+}
+
+func TestEval_RangeRejectsInvertedRange(t *testing.T) {
+	store := storage.NewMetricStore(100)
+	expr, err := Parse(`cpu.usage`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	if _, err := NewEvaluator(store).EvalRange(expr, 10000, 0, 1000); err == nil {
+		t.Fatal("expected an error when start is after end, got nil")
+	}
+}
+
+func TestEval_RangeRejectsExcessiveSamples(t *testing.T) {
+	store := storage.NewMetricStore(100)
+	expr, err := Parse(`cpu.usage`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	const hundredYearsMs = int64(100) * 365 * 24 * 3600 * 1000
+	if _, err := NewEvaluator(store).EvalRange(expr, 0, hundredYearsMs, 1); err == nil {
+		t.Fatal("expected an error when the requested step would produce too many samples, got nil")
+	}
+}
+
+func TestEval_RangeQueryStepAlignment(t *testing.T) {
+	store := storage.NewMetricStore(100)
+	for ts := int64(0); ts <= 10000; ts += 1000 {
+		store.Append(storage.MetricPoint{Timestamp: ts, MetricName: "cpu.usage", Value: float64(ts)})
+	}
+
+	expr, err := Parse(`cpu.usage`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	series, err := NewEvaluator(store).EvalRange(expr, 0, 10000, 5000)
+	if err != nil {
+		t.Fatalf("eval error: %v", err)
+	}
+	if len(series) != 1 {
+		t.Fatalf("expected 1 series, got %d", len(series))
+	}
+	if len(series[0].Samples) != 3 {
+		t.Fatalf("expected 3 step-aligned samples, got %d", len(series[0].Samples))
+	}
+}
@@ -0,0 +1,53 @@
+package query
+
+import (
+	"sort"
+	"time"
+)
+
+// LabelNames returns every distinct label name currently stored, plus the
+// synthetic __name__ label for the metric name itself.
+func LabelNames(store Store) []string {
+	seen := map[string]bool{"__name__": true}
+	now := time.Now().UnixMilli()
+
+	for _, metric := range store.GetAllMetrics() {
+		for _, p := range store.GetRange(metric, 0, now) {
+			for k := range p.Labels {
+				seen[k] = true
+			}
+		}
+	}
+
+	return sortedKeys(seen)
+}
+
+// LabelValues returns every distinct value observed for a given label name
+// (or metric name, for __name__).
+func LabelValues(store Store, name string) []string {
+	seen := make(map[string]bool)
+	now := time.Now().UnixMilli()
+
+	for _, metric := range store.GetAllMetrics() {
+		if name == "__name__" {
+			seen[metric] = true
+			continue
+		}
+		for _, p := range store.GetRange(metric, 0, now) {
+			if v, ok := p.Labels[name]; ok {
+				seen[v] = true
+			}
+		}
+	}
+
+	return sortedKeys(seen)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,176 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokLBrace
+	tokRBrace
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokComma
+	tokEq
+	tokNeq
+	tokEqRegex
+	tokNeqRegex
+	tokPlus
+	tokMinus
+	tokStar
+	tokSlash
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// lexer tokenizes a query expression. It's a hand-rolled scanner rather than
+// a generated one since the grammar is small and fixed.
+type lexer struct {
+	input []rune
+	pos   int
+}
+
+func newLexer(s string) *lexer {
+	return &lexer{input: []rune(s)}
+}
+
+func (l *lexer) peekRune() rune {
+	if l.pos >= len(l.input) {
+		return 0
+	}
+	return l.input[l.pos]
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.input) && unicode.IsSpace(l.input[l.pos]) {
+		l.pos++
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipSpace()
+	if l.pos >= len(l.input) {
+		return token{kind: tokEOF}, nil
+	}
+
+	c := l.input[l.pos]
+	switch {
+	case c == '{':
+		l.pos++
+		return token{kind: tokLBrace, text: "{"}, nil
+	case c == '}':
+		l.pos++
+		return token{kind: tokRBrace, text: "}"}, nil
+	case c == '(':
+		l.pos++
+		return token{kind: tokLParen, text: "("}, nil
+	case c == ')':
+		l.pos++
+		return token{kind: tokRParen, text: ")"}, nil
+	case c == '[':
+		l.pos++
+		return token{kind: tokLBracket, text: "["}, nil
+	case c == ']':
+		l.pos++
+		return token{kind: tokRBracket, text: "]"}, nil
+	case c == ',':
+		l.pos++
+		return token{kind: tokComma, text: ","}, nil
+	case c == '+':
+		l.pos++
+		return token{kind: tokPlus, text: "+"}, nil
+	case c == '-':
+		l.pos++
+		return token{kind: tokMinus, text: "-"}, nil
+	case c == '*':
+		l.pos++
+		return token{kind: tokStar, text: "*"}, nil
+	case c == '/':
+		l.pos++
+		return token{kind: tokSlash, text: "/"}, nil
+	case c == '=':
+		l.pos++
+		if l.peekRune() == '~' {
+			l.pos++
+			return token{kind: tokEqRegex, text: "=~"}, nil
+		}
+		return token{kind: tokEq, text: "="}, nil
+	case c == '!':
+		l.pos++
+		switch l.peekRune() {
+		case '~':
+			l.pos++
+			return token{kind: tokNeqRegex, text: "!~"}, nil
+		case '=':
+			l.pos++
+			return token{kind: tokNeq, text: "!="}, nil
+		default:
+			return token{}, fmt.Errorf("unexpected character %q", c)
+		}
+	case c == '"':
+		return l.lexString()
+	case unicode.IsDigit(c):
+		return l.lexNumber()
+	case isIdentStart(c):
+		return l.lexIdent()
+	default:
+		return token{}, fmt.Errorf("unexpected character %q", c)
+	}
+}
+
+func (l *lexer) lexString() (token, error) {
+	l.pos++ // consume opening quote
+	var b strings.Builder
+	for {
+		if l.pos >= len(l.input) {
+			return token{}, fmt.Errorf("unterminated string literal")
+		}
+		c := l.input[l.pos]
+		if c == '"' {
+			l.pos++
+			return token{kind: tokString, text: b.String()}, nil
+		}
+		if c == '\\' && l.pos+1 < len(l.input) {
+			l.pos++
+			c = l.input[l.pos]
+		}
+		b.WriteRune(c)
+		l.pos++
+	}
+}
+
+func (l *lexer) lexNumber() (token, error) {
+	start := l.pos
+	for l.pos < len(l.input) && (unicode.IsDigit(l.input[l.pos]) || l.input[l.pos] == '.') {
+		l.pos++
+	}
+	return token{kind: tokNumber, text: string(l.input[start:l.pos])}, nil
+}
+
+func (l *lexer) lexIdent() (token, error) {
+	start := l.pos
+	for l.pos < len(l.input) && isIdentPart(l.input[l.pos]) {
+		l.pos++
+	}
+	return token{kind: tokIdent, text: string(l.input[start:l.pos])}, nil
+}
+
+func isIdentStart(c rune) bool {
+	return unicode.IsLetter(c) || c == '_' || c == ':'
+}
+
+func isIdentPart(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == ':' || c == '.'
+}
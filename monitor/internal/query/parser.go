@@ -0,0 +1,258 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var aggrOps = map[string]bool{"sum": true, "avg": true, "max": true, "min": true}
+
+// Parse parses a query expression: a metric selector with label matchers
+// (`cpu.usage{host="web1",region=~"us-.*"}`), an aggregation
+// (`sum by(host) (...)`), a range-vector call (`rate(metric[1m])`), or
+// arithmetic between vectors (`a / b`).
+func Parse(input string) (Expr, error) {
+	lx := newLexer(input)
+
+	var tokens []token
+	for {
+		tok, err := lx.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		if tok.kind == tokEOF {
+			break
+		}
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.atEOF() {
+		return nil, fmt.Errorf("unexpected trailing input at %q", p.peek().text)
+	}
+	return expr, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	if p.pos >= len(p.tokens) {
+		return token{kind: tokEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) atEOF() bool {
+	return p.peek().kind == tokEOF
+}
+
+func (p *parser) expect(kind tokenKind, desc string) (token, error) {
+	if p.peek().kind != kind {
+		return token{}, fmt.Errorf("expected %s, got %q", desc, p.peek().text)
+	}
+	return p.next(), nil
+}
+
+// parseExpr parses +/- at the lowest precedence, */ above that.
+func (p *parser) parseExpr() (Expr, error) {
+	return p.parseAddSub()
+}
+
+func (p *parser) parseAddSub() (Expr, error) {
+	lhs, err := p.parseMulDiv()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokPlus || p.peek().kind == tokMinus {
+		op := p.next().text
+		rhs, err := p.parseMulDiv()
+		if err != nil {
+			return nil, err
+		}
+		lhs = &BinaryExpr{Op: op, LHS: lhs, RHS: rhs}
+	}
+	return lhs, nil
+}
+
+func (p *parser) parseMulDiv() (Expr, error) {
+	lhs, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokStar || p.peek().kind == tokSlash {
+		op := p.next().text
+		rhs, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		lhs = &BinaryExpr{Op: op, LHS: lhs, RHS: rhs}
+	}
+	return lhs, nil
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.peek()
+
+	switch {
+	case tok.kind == tokLParen:
+		p.next()
+		inner, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+
+	case tok.kind == tokIdent && aggrOps[tok.text]:
+		return p.parseAggr()
+
+	case tok.kind == tokIdent && tok.text == "rate":
+		return p.parseCall("rate")
+
+	case tok.kind == tokIdent:
+		return p.parseVectorSelector()
+
+	default:
+		return nil, fmt.Errorf("unexpected token %q", tok.text)
+	}
+}
+
+func (p *parser) parseAggr() (Expr, error) {
+	op := p.next().text
+
+	var by []string
+	if p.peek().kind == tokIdent && p.peek().text == "by" {
+		p.next()
+		if _, err := p.expect(tokLParen, "'(' after by"); err != nil {
+			return nil, err
+		}
+		for p.peek().kind != tokRParen {
+			name, err := p.expect(tokIdent, "label name in by()")
+			if err != nil {
+				return nil, err
+			}
+			by = append(by, name.text)
+			if p.peek().kind == tokComma {
+				p.next()
+			}
+		}
+		p.next() // consume ')'
+	}
+
+	if _, err := p.expect(tokLParen, fmt.Sprintf("'(' after aggregation operator %s", op)); err != nil {
+		return nil, err
+	}
+	inner, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokRParen, "')'"); err != nil {
+		return nil, err
+	}
+
+	return &AggrExpr{Op: op, By: by, Expr: inner}, nil
+}
+
+func (p *parser) parseCall(name string) (Expr, error) {
+	p.next() // consume function name
+	if _, err := p.expect(tokLParen, fmt.Sprintf("'(' after %s", name)); err != nil {
+		return nil, err
+	}
+	arg, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokRParen, "')'"); err != nil {
+		return nil, err
+	}
+	return &CallExpr{Func: name, Args: []Expr{arg}}, nil
+}
+
+func (p *parser) parseVectorSelector() (Expr, error) {
+	name := p.next().text
+	sel := &VectorSelector{Metric: name}
+
+	if p.peek().kind == tokLBrace {
+		p.next()
+		for p.peek().kind != tokRBrace {
+			m, err := p.parseMatcher()
+			if err != nil {
+				return nil, err
+			}
+			sel.Matchers = append(sel.Matchers, m)
+			if p.peek().kind == tokComma {
+				p.next()
+			}
+		}
+		p.next() // consume '}'
+	}
+
+	if p.peek().kind == tokLBracket {
+		p.next()
+		var raw strings.Builder
+		for p.peek().kind != tokRBracket {
+			if p.atEOF() {
+				return nil, fmt.Errorf("unterminated range selector")
+			}
+			raw.WriteString(p.next().text)
+		}
+		p.next() // consume ']'
+
+		d, err := time.ParseDuration(raw.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid range duration %q: %w", raw.String(), err)
+		}
+		sel.Range = d
+	}
+
+	return sel, nil
+}
+
+func (p *parser) parseMatcher() (*LabelMatcher, error) {
+	name, err := p.expect(tokIdent, "label name")
+	if err != nil {
+		return nil, err
+	}
+
+	var regex, negate bool
+	switch p.peek().kind {
+	case tokEq:
+		p.next()
+	case tokEqRegex:
+		regex = true
+		p.next()
+	case tokNeq:
+		negate = true
+		p.next()
+	case tokNeqRegex:
+		regex, negate = true, true
+		p.next()
+	default:
+		return nil, fmt.Errorf("expected '=', '!=', '=~', or '!~' after label name %s", name.text)
+	}
+
+	value, err := p.expect(tokString, fmt.Sprintf("quoted string value for label %s", name.text))
+	if err != nil {
+		return nil, err
+	}
+
+	return &LabelMatcher{Name: name.text, Value: value.text, Regex: regex, Negate: negate}, nil
+}
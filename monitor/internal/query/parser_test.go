@@ -0,0 +1,73 @@
+package query
+
+import "testing"
+
+func TestParse_SimpleSelector(t *testing.T) {
+	expr, err := Parse(`cpu.usage`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sel, ok := expr.(*VectorSelector)
+	if !ok {
+		t.Fatalf("expected *VectorSelector, got %T", expr)
+	}
+	if sel.Metric != "cpu.usage" {
+		t.Errorf("expected metric cpu.usage, got %q", sel.Metric)
+	}
+}
+
+func TestParse_SelectorWithMatchers(t *testing.T) {
+	expr, err := Parse(`cpu.usage{host="web1",region=~"us-.*"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sel := expr.(*VectorSelector)
+	if len(sel.Matchers) != 2 {
+		t.Fatalf("expected 2 matchers, got %d", len(sel.Matchers))
+	}
+	if sel.Matchers[0].Name != "host" || sel.Matchers[0].Value != "web1" || sel.Matchers[0].Regex {
+		t.Errorf("unexpected matcher 0: %+v", sel.Matchers[0])
+	}
+	if sel.Matchers[1].Name != "region" || !sel.Matchers[1].Regex {
+		t.Errorf("unexpected matcher 1: %+v", sel.Matchers[1])
+	}
+}
+
+func TestParse_AggrByAndRate(t *testing.T) {
+	expr, err := Parse(`sum by(host) (rate(cpu.usage[1m]))`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	aggr, ok := expr.(*AggrExpr)
+	if !ok {
+		t.Fatalf("expected *AggrExpr, got %T", expr)
+	}
+	if aggr.Op != "sum" || len(aggr.By) != 1 || aggr.By[0] != "host" {
+		t.Errorf("unexpected aggr: %+v", aggr)
+	}
+	call, ok := aggr.Expr.(*CallExpr)
+	if !ok || call.Func != "rate" {
+		t.Fatalf("expected rate() call, got %+v", aggr.Expr)
+	}
+	sel := call.Args[0].(*VectorSelector)
+	if sel.Range.String() != "1m0s" {
+		t.Errorf("expected 1m range, got %s", sel.Range)
+	}
+}
+
+func TestParse_BinaryExpr(t *testing.T) {
+	expr, err := Parse(`errors.total / requests.total`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bin, ok := expr.(*BinaryExpr)
+	if !ok || bin.Op != "/" {
+		t.Fatalf("expected binary '/' expr, got %+v", expr)
+	}
+}
+
+func TestParse_InvalidSyntax(t *testing.T) {
+	if _, err := Parse(`sum(`); err == nil {
+		t.Error("expected error for unterminated aggregation, got nil")
+	}
+}
@@ -0,0 +1,14 @@
+package query
+
+// Sample is one evaluated (timestamp, value) pair.
+type Sample struct {
+	TimestampMs int64
+	Value       float64
+}
+
+// Series is one label-set's worth of samples produced by evaluating an
+// expression. Labels includes __name__ for vector selectors.
+type Series struct {
+	Labels  map[string]string
+	Samples []Sample
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/ag-botkit/monitor/internal/storage"
+)
+
+// clientFilter is a dashboard client's subscription, compiled once from its
+// query params at connect time so the broadcast loop can test interest
+// without re-parsing anything per metric.
+type clientFilter struct {
+	metricGlob string
+	labels     map[string]string
+}
+
+// newClientFilter builds a clientFilter from a /dashboard request, e.g.
+// `?metric=cpu.*&labels=host=web1,region=us-east`. A zero-value filter
+// (no metric glob, no labels) matches everything.
+func newClientFilter(r *http.Request) *clientFilter {
+	q := r.URL.Query()
+
+	f := &clientFilter{metricGlob: q.Get("metric")}
+
+	if raw := q.Get("labels"); raw != "" {
+		f.labels = make(map[string]string)
+		for _, pair := range strings.Split(raw, ",") {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) == 2 {
+				f.labels[kv[0]] = kv[1]
+			}
+		}
+	}
+
+	return f
+}
+
+// matchesName reports whether a metric name satisfies the filter's glob,
+// independent of labels. Useful to cheaply narrow down which stored metrics
+// are worth scanning for replay.
+func (f *clientFilter) matchesName(metricName string) bool {
+	if f.metricGlob == "" {
+		return true
+	}
+	ok, err := filepath.Match(f.metricGlob, metricName)
+	return err == nil && ok
+}
+
+// matches reports whether a point satisfies both the metric glob and every
+// label equality constraint.
+func (f *clientFilter) matches(p *storage.MetricPoint) bool {
+	if !f.matchesName(p.MetricName) {
+		return false
+	}
+	for k, v := range f.labels {
+		if p.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/ag-botkit/monitor/internal/storage"
+)
+
+func TestNewClientFilter(t *testing.T) {
+	r := &http.Request{URL: &url.URL{RawQuery: "metric=cpu.*&labels=host=web1,region=us-east"}}
+	f := newClientFilter(r)
+
+	if f.metricGlob != "cpu.*" {
+		t.Errorf("metricGlob = %q, want %q", f.metricGlob, "cpu.*")
+	}
+	if f.labels["host"] != "web1" || f.labels["region"] != "us-east" {
+		t.Errorf("unexpected labels: %v", f.labels)
+	}
+}
+
+func TestClientFilter_Matches(t *testing.T) {
+	cases := []struct {
+		name   string
+		filter *clientFilter
+		point  storage.MetricPoint
+		want   bool
+	}{
+		{
+			name:   "zero-value filter matches everything",
+			filter: &clientFilter{},
+			point:  storage.MetricPoint{MetricName: "cpu.usage"},
+			want:   true,
+		},
+		{
+			name:   "metric glob matches",
+			filter: &clientFilter{metricGlob: "cpu.*"},
+			point:  storage.MetricPoint{MetricName: "cpu.usage"},
+			want:   true,
+		},
+		{
+			name:   "metric glob rejects",
+			filter: &clientFilter{metricGlob: "cpu.*"},
+			point:  storage.MetricPoint{MetricName: "mem.usage"},
+			want:   false,
+		},
+		{
+			name:   "label constraint matches",
+			filter: &clientFilter{labels: map[string]string{"host": "web1"}},
+			point:  storage.MetricPoint{MetricName: "cpu.usage", Labels: map[string]string{"host": "web1"}},
+			want:   true,
+		},
+		{
+			name:   "label constraint rejects on mismatch",
+			filter: &clientFilter{labels: map[string]string{"host": "web1"}},
+			point:  storage.MetricPoint{MetricName: "cpu.usage", Labels: map[string]string{"host": "web2"}},
+			want:   false,
+		},
+		{
+			name:   "label constraint rejects on missing label",
+			filter: &clientFilter{labels: map[string]string{"host": "web1"}},
+			point:  storage.MetricPoint{MetricName: "cpu.usage"},
+			want:   false,
+		},
+		{
+			name:   "glob and labels both required",
+			filter: &clientFilter{metricGlob: "cpu.*", labels: map[string]string{"host": "web1"}},
+			point:  storage.MetricPoint{MetricName: "cpu.usage", Labels: map[string]string{"host": "web2"}},
+			want:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.filter.matches(&tc.point); got != tc.want {
+				t.Errorf("matches() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"expvar"
 	"log"
 	"net/http"
 	"time"
@@ -10,9 +11,10 @@ import (
 
 // Server represents the HTTP server
 type Server struct {
-	store  *storage.MetricStore
-	hub    *Hub
-	server *http.Server
+	store     *storage.MetricStore
+	hub       *Hub
+	server    *http.Server
+	startedAt time.Time
 }
 
 // NewServer creates a new HTTP server
@@ -20,8 +22,9 @@ func NewServer(addr string, store *storage.MetricStore) *Server {
 	hub := NewHub(store)
 
 	s := &Server{
-		store: store,
-		hub:   hub,
+		store:     store,
+		hub:       hub,
+		startedAt: time.Now(),
 		server: &http.Server{
 			Addr:         addr,
 			ReadTimeout:  15 * time.Second,
@@ -36,14 +39,33 @@ func NewServer(addr string, store *storage.MetricStore) *Server {
 	return s
 }
 
+// Hub returns the server's WebSocket hub, e.g. so callers can wire an
+// aggregator into the ingestion pipeline with hub.SetAggregator.
+func (s *Server) Hub() *Hub {
+	return s.hub
+}
+
 // SetupRoutes configures HTTP routes
 func (s *Server) SetupRoutes(staticFS http.FileSystem) {
 	mux := http.NewServeMux()
 
-	// WebSocket endpoints
-	mux.HandleFunc("/metrics", s.hub.HandleMetricsWS)
+	// WebSocket ingestion, plus a plain-HTTP scrape target on the same path
+	mux.HandleFunc("/metrics", s.handleMetricsEndpoint)
 	mux.HandleFunc("/dashboard", s.hub.HandleDashboardWS)
 
+	// Prometheus remote_write ingestion
+	mux.HandleFunc("/api/v1/write", s.handleRemoteWrite)
+
+	// Query API (PromQL-style selectors over the store)
+	mux.HandleFunc("/api/v1/query", s.handleQuery)
+	mux.HandleFunc("/api/v1/query_range", s.handleQueryRange)
+	mux.HandleFunc("/api/v1/labels", s.handleLabels)
+	mux.HandleFunc("/api/v1/label/", s.handleLabelValues)
+
+	// Operational introspection
+	mux.HandleFunc("/api/v1/status", s.handleStatus)
+	mux.Handle("/debug/vars", expvar.Handler())
+
 	// Static files
 	mux.Handle("/", http.FileServer(staticFS))
 
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ag-botkit/monitor/internal/query"
+)
+
+// promResponse mirrors the shape of Prometheus's HTTP API responses so
+// existing tools (Grafana's Prometheus data source, promtool, ...) can point
+// at ag-botkit directly.
+type promResponse struct {
+	Status string    `json:"status"`
+	Data   *promData `json:"data,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+type promData struct {
+	ResultType string       `json:"resultType"`
+	Result     []promSeries `json:"result"`
+}
+
+type promSeries struct {
+	Metric map[string]string `json:"metric"`
+	Value  *[2]interface{}    `json:"value,omitempty"`
+	Values [][2]interface{}   `json:"values,omitempty"`
+}
+
+// handleQuery serves /api/v1/query (instant queries).
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	exprStr := r.URL.Query().Get("query")
+	if exprStr == "" {
+		writeQueryError(w, http.StatusBadRequest, fmt.Errorf("missing query parameter"))
+		return
+	}
+
+	tMs := time.Now().UnixMilli()
+	if raw := r.URL.Query().Get("time"); raw != "" {
+		parsed, err := parsePromTimestamp(raw)
+		if err != nil {
+			writeQueryError(w, http.StatusBadRequest, fmt.Errorf("invalid time: %w", err))
+			return
+		}
+		tMs = parsed
+	}
+
+	expr, err := query.Parse(exprStr)
+	if err != nil {
+		writeQueryError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	series, err := query.NewEvaluator(s.store).EvalInstant(expr, tMs)
+	if err != nil {
+		writeQueryError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	writeQueryResult(w, "vector", series, false)
+}
+
+// handleQueryRange serves /api/v1/query_range.
+func (s *Server) handleQueryRange(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	exprStr := q.Get("query")
+	if exprStr == "" {
+		writeQueryError(w, http.StatusBadRequest, fmt.Errorf("missing query parameter"))
+		return
+	}
+
+	startMs, err := parsePromTimestamp(q.Get("start"))
+	if err != nil {
+		writeQueryError(w, http.StatusBadRequest, fmt.Errorf("invalid start: %w", err))
+		return
+	}
+	endMs, err := parsePromTimestamp(q.Get("end"))
+	if err != nil {
+		writeQueryError(w, http.StatusBadRequest, fmt.Errorf("invalid end: %w", err))
+		return
+	}
+	stepMs, err := parsePromStep(q.Get("step"))
+	if err != nil {
+		writeQueryError(w, http.StatusBadRequest, fmt.Errorf("invalid step: %w", err))
+		return
+	}
+	if startMs > endMs {
+		writeQueryError(w, http.StatusBadRequest, fmt.Errorf("start must not be after end"))
+		return
+	}
+
+	expr, err := query.Parse(exprStr)
+	if err != nil {
+		writeQueryError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	series, err := query.NewEvaluator(s.store).EvalRange(expr, startMs, endMs, stepMs)
+	if err != nil {
+		writeQueryError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	writeQueryResult(w, "matrix", series, true)
+}
+
+// handleLabels serves /api/v1/labels.
+func (s *Server) handleLabels(w http.ResponseWriter, r *http.Request) {
+	writeStringList(w, query.LabelNames(s.store))
+}
+
+// handleLabelValues serves /api/v1/label/<name>/values.
+func (s *Server) handleLabelValues(w http.ResponseWriter, r *http.Request) {
+	name, ok := labelNameFromPath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeStringList(w, query.LabelValues(s.store, name))
+}
+
+func labelNameFromPath(path string) (string, bool) {
+	const prefix = "/api/v1/label/"
+	const suffix = "/values"
+	if !strings.HasPrefix(path, prefix) || !strings.HasSuffix(path, suffix) {
+		return "", false
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(path, prefix), suffix)
+	if name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+func writeStringList(w http.ResponseWriter, values []string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Status string   `json:"status"`
+		Data   []string `json:"data"`
+	}{Status: "success", Data: values})
+}
+
+func writeQueryError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(promResponse{Status: "error", Error: err.Error()})
+}
+
+func writeQueryResult(w http.ResponseWriter, resultType string, series []query.Series, isRange bool) {
+	result := make([]promSeries, 0, len(series))
+	for _, s := range series {
+		ps := promSeries{Metric: s.Labels}
+		if isRange {
+			ps.Values = make([][2]interface{}, 0, len(s.Samples))
+			for _, sample := range s.Samples {
+				ps.Values = append(ps.Values, promPair(sample))
+			}
+		} else if len(s.Samples) > 0 {
+			v := promPair(s.Samples[len(s.Samples)-1])
+			ps.Value = &v
+		}
+		result = append(result, ps)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(promResponse{
+		Status: "success",
+		Data:   &promData{ResultType: resultType, Result: result},
+	})
+}
+
+func promPair(s query.Sample) [2]interface{} {
+	return [2]interface{}{float64(s.TimestampMs) / 1000, strconv.FormatFloat(s.Value, 'f', -1, 64)}
+}
+
+// parsePromTimestamp accepts the same timestamp forms as Prometheus's HTTP
+// API: a unix timestamp (optionally fractional, for sub-second precision) or
+// an RFC3339 string.
+func parsePromTimestamp(raw string) (int64, error) {
+	if raw == "" {
+		return 0, fmt.Errorf("missing timestamp")
+	}
+	if secs, err := strconv.ParseFloat(raw, 64); err == nil {
+		return int64(secs * 1000), nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q", raw)
+	}
+	return t.UnixMilli(), nil
+}
+
+// parsePromStep accepts either a Go duration ("30s") or a bare number of
+// seconds ("30"), matching Prometheus's query_range `step` param.
+func parsePromStep(raw string) (int64, error) {
+	if raw == "" {
+		return 0, fmt.Errorf("missing step")
+	}
+	if secs, err := strconv.ParseFloat(raw, 64); err == nil {
+		return int64(secs * 1000), nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid step %q", raw)
+	}
+	return d.Milliseconds(), nil
+}
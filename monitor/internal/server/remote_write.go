@@ -0,0 +1,111 @@
+package server
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/ag-botkit/monitor/internal/storage"
+	"github.com/golang/snappy"
+	"github.com/gorilla/websocket"
+)
+
+// handleMetricsEndpoint multiplexes the legacy WebSocket ingestion protocol
+// and a plain-HTTP Prometheus/OpenMetrics scrape target onto the existing
+// /metrics path, so exporters can be pointed at ag-botkit without any
+// client-side changes.
+func (s *Server) handleMetricsEndpoint(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		s.hub.HandleMetricsWS(w, r)
+		return
+	}
+	s.handleScrapeIngest(w, r)
+}
+
+// handleScrapeIngest accepts a Prometheus/OpenMetrics text exposition body
+// (as produced by `curl http://exporter/metrics`) and feeds every sample into
+// the same store+broadcast pipeline as the WebSocket and remote_write paths.
+func (s *Server) handleScrapeIngest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	points, err := parseExposition(r.Body)
+	if err != nil {
+		s.hub.RecordIngestDrop()
+		http.Error(w, "failed to parse exposition body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, p := range points {
+		s.hub.Ingest(p)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRemoteWrite accepts a Prometheus remote_write request: a
+// snappy-framed, protobuf-encoded WriteRequest (decoded by
+// decodeRemoteWriteRequest, see remote_write_proto.go). Each TimeSeries
+// becomes one MetricPoint per sample, with the `__name__` label promoted to
+// MetricName and the remaining labels carried through as-is.
+func (s *Server) handleRemoteWrite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	compressed, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		s.hub.RecordIngestDrop()
+		http.Error(w, "failed to decode snappy payload", http.StatusBadRequest)
+		return
+	}
+
+	req, err := decodeRemoteWriteRequest(data)
+	if err != nil {
+		s.hub.RecordIngestDrop()
+		http.Error(w, "failed to unmarshal write request", http.StatusBadRequest)
+		return
+	}
+
+	for _, ts := range req.Timeseries {
+		metricName, labels := splitRemoteWriteLabels(ts.Labels)
+		if metricName == "" {
+			continue
+		}
+
+		for _, sample := range ts.Samples {
+			s.hub.Ingest(storage.MetricPoint{
+				Timestamp:  sample.Timestamp,
+				MetricType: "gauge",
+				MetricName: metricName,
+				Value:      sample.Value,
+				Labels:     labels,
+			})
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// splitRemoteWriteLabels pulls the `__name__` label (the metric name, in
+// Prometheus's data model) out of a remote_write label list and returns the
+// rest as a plain label map.
+func splitRemoteWriteLabels(pairs []remoteWriteLabel) (metricName string, labels map[string]string) {
+	labels = make(map[string]string, len(pairs))
+	for _, l := range pairs {
+		if l.Name == "__name__" {
+			metricName = l.Value
+			continue
+		}
+		labels[l.Name] = l.Value
+	}
+	return metricName, labels
+}
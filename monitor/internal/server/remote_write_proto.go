@@ -0,0 +1,224 @@
+package server
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// This file hand-decodes the small slice of the Prometheus remote_write wire
+// format (https://prometheus.io/docs/concepts/remote_write_spec/) that
+// ag-botkit actually needs: WriteRequest{TimeSeries{Label, Sample}}. Pulling
+// in github.com/prometheus/prometheus/prompb for three structs drags in the
+// entire prometheus/prometheus server module (prometheus/common,
+// gogo/protobuf, grafana/regexp, golang.org/x/net, golang.org/x/text) as
+// transitive dependencies, so we decode the protobuf wire format directly
+// instead.
+
+// remoteWriteLabel is one label (field 1 = name, field 2 = value).
+type remoteWriteLabel struct {
+	Name  string
+	Value string
+}
+
+// remoteWriteSample is one sample (field 1 = value, field 2 = timestamp).
+type remoteWriteSample struct {
+	Value     float64
+	Timestamp int64
+}
+
+// remoteWriteSeries is one series (field 1 = repeated labels, field 2 =
+// repeated samples). Exemplars and native histograms aren't decoded; ag-botkit
+// doesn't model either.
+type remoteWriteSeries struct {
+	Labels  []remoteWriteLabel
+	Samples []remoteWriteSample
+}
+
+// remoteWriteRequest is the top-level message (field 1 = repeated TimeSeries).
+// Metadata (field 3) isn't decoded; ag-botkit has no use for it.
+type remoteWriteRequest struct {
+	Timeseries []remoteWriteSeries
+}
+
+// decodeRemoteWriteRequest parses a remote_write WriteRequest from its raw
+// (already snappy-decompressed) protobuf bytes.
+func decodeRemoteWriteRequest(data []byte) (remoteWriteRequest, error) {
+	var req remoteWriteRequest
+
+	err := eachField(data, func(fieldNum int, wireType int, raw []byte) error {
+		if fieldNum != 1 || wireType != wireBytes {
+			return nil
+		}
+		ts, err := decodeTimeSeries(raw)
+		if err != nil {
+			return fmt.Errorf("timeseries %d: %w", len(req.Timeseries), err)
+		}
+		req.Timeseries = append(req.Timeseries, ts)
+		return nil
+	})
+	return req, err
+}
+
+func decodeTimeSeries(data []byte) (remoteWriteSeries, error) {
+	var ts remoteWriteSeries
+
+	err := eachField(data, func(fieldNum int, wireType int, raw []byte) error {
+		if wireType != wireBytes {
+			return nil
+		}
+		switch fieldNum {
+		case 1:
+			label, err := decodeLabel(raw)
+			if err != nil {
+				return fmt.Errorf("label: %w", err)
+			}
+			ts.Labels = append(ts.Labels, label)
+		case 2:
+			sample, err := decodeSample(raw)
+			if err != nil {
+				return fmt.Errorf("sample: %w", err)
+			}
+			ts.Samples = append(ts.Samples, sample)
+		}
+		return nil
+	})
+	return ts, err
+}
+
+func decodeLabel(data []byte) (remoteWriteLabel, error) {
+	var label remoteWriteLabel
+
+	err := eachField(data, func(fieldNum int, wireType int, raw []byte) error {
+		if wireType != wireBytes {
+			return nil
+		}
+		switch fieldNum {
+		case 1:
+			label.Name = string(raw)
+		case 2:
+			label.Value = string(raw)
+		}
+		return nil
+	})
+	return label, err
+}
+
+func decodeSample(data []byte) (remoteWriteSample, error) {
+	var sample remoteWriteSample
+
+	pos := 0
+	for pos < len(data) {
+		tag, n, err := decodeVarint(data[pos:])
+		if err != nil {
+			return sample, err
+		}
+		pos += n
+
+		fieldNum := int(tag >> 3)
+		wireType := int(tag & 0x7)
+
+		switch wireType {
+		case wireVarint:
+			v, n, err := decodeVarint(data[pos:])
+			if err != nil {
+				return sample, err
+			}
+			pos += n
+			if fieldNum == 2 {
+				sample.Timestamp = int64(v)
+			}
+		case wireFixed64:
+			if pos+8 > len(data) {
+				return sample, fmt.Errorf("truncated fixed64 field")
+			}
+			bits := binary.LittleEndian.Uint64(data[pos : pos+8])
+			pos += 8
+			if fieldNum == 1 {
+				sample.Value = math.Float64frombits(bits)
+			}
+		default:
+			return sample, fmt.Errorf("sample: unsupported wire type %d", wireType)
+		}
+	}
+	return sample, nil
+}
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// eachField walks the top-level fields of a protobuf message, invoking fn
+// with the raw (still-encoded) payload of each length-delimited field; other
+// wire types are skipped since none of the messages decoded here need them
+// at the top level.
+func eachField(data []byte, fn func(fieldNum, wireType int, raw []byte) error) error {
+	pos := 0
+	for pos < len(data) {
+		tag, n, err := decodeVarint(data[pos:])
+		if err != nil {
+			return err
+		}
+		pos += n
+
+		fieldNum := int(tag >> 3)
+		wireType := int(tag & 0x7)
+
+		switch wireType {
+		case wireVarint:
+			_, n, err := decodeVarint(data[pos:])
+			if err != nil {
+				return err
+			}
+			pos += n
+
+		case wireFixed64:
+			if pos+8 > len(data) {
+				return fmt.Errorf("truncated fixed64 field")
+			}
+			pos += 8
+
+		case wireFixed32:
+			if pos+4 > len(data) {
+				return fmt.Errorf("truncated fixed32 field")
+			}
+			pos += 4
+
+		case wireBytes:
+			length, n, err := decodeVarint(data[pos:])
+			if err != nil {
+				return err
+			}
+			pos += n
+			if pos+int(length) > len(data) {
+				return fmt.Errorf("truncated length-delimited field")
+			}
+			raw := data[pos : pos+int(length)]
+			pos += int(length)
+			if err := fn(fieldNum, wireType, raw); err != nil {
+				return err
+			}
+
+		default:
+			return fmt.Errorf("unsupported wire type %d", wireType)
+		}
+	}
+	return nil
+}
+
+// decodeVarint reads a base-128 varint from the start of data, returning the
+// decoded value and the number of bytes consumed.
+func decodeVarint(data []byte) (uint64, int, error) {
+	var v uint64
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		v |= uint64(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("truncated varint")
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"math"
+	"testing"
+)
+
+// encodeVarint, encodeBytesField, encodeLabel and encodeSample build the
+// minimal protobuf wire bytes needed to exercise decodeRemoteWriteRequest without a real
+// protobuf library.
+func encodeVarint(v uint64) []byte {
+	var out []byte
+	for v >= 0x80 {
+		out = append(out, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(out, byte(v))
+}
+
+func encodeBytesField(fieldNum int, payload []byte) []byte {
+	tag := encodeVarint(uint64(fieldNum)<<3 | wireBytes)
+	return append(append(tag, encodeVarint(uint64(len(payload)))...), payload...)
+}
+
+func encodeLabel(name, value string) []byte {
+	var out []byte
+	out = append(out, encodeBytesField(1, []byte(name))...)
+	out = append(out, encodeBytesField(2, []byte(value))...)
+	return out
+}
+
+func encodeSample(value float64, timestamp int64) []byte {
+	var out []byte
+	tag := encodeVarint(uint64(1)<<3 | wireFixed64)
+	out = append(out, tag...)
+	bits := math.Float64bits(value)
+	for i := 0; i < 8; i++ {
+		out = append(out, byte(bits>>(8*i)))
+	}
+	out = append(out, encodeVarint(uint64(2)<<3|wireVarint)...)
+	out = append(out, encodeVarint(uint64(timestamp))...)
+	return out
+}
+
+func TestDecodeRemoteWriteRequest(t *testing.T) {
+	label := encodeLabel("__name__", "cpu.usage")
+	label2 := encodeLabel("host", "web1")
+	sample := encodeSample(42.5, 1000)
+
+	var series []byte
+	series = append(series, encodeBytesField(1, label)...)
+	series = append(series, encodeBytesField(1, label2)...)
+	series = append(series, encodeBytesField(2, sample)...)
+
+	var req []byte
+	req = append(req, encodeBytesField(1, series)...)
+
+	got, err := decodeRemoteWriteRequest(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Timeseries) != 1 {
+		t.Fatalf("expected 1 series, got %d", len(got.Timeseries))
+	}
+
+	ts := got.Timeseries[0]
+	if len(ts.Labels) != 2 || len(ts.Samples) != 1 {
+		t.Fatalf("unexpected series shape: %+v", ts)
+	}
+
+	name, labels := splitRemoteWriteLabels(ts.Labels)
+	if name != "cpu.usage" {
+		t.Errorf("expected metric name cpu.usage, got %q", name)
+	}
+	if labels["host"] != "web1" {
+		t.Errorf("expected host=web1, got %v", labels)
+	}
+	if ts.Samples[0].Timestamp != 1000 {
+		t.Errorf("expected timestamp 1000, got %d", ts.Samples[0].Timestamp)
+	}
+	if ts.Samples[0].Value != 42.5 {
+		t.Errorf("expected value 42.5, got %f", ts.Samples[0].Value)
+	}
+}
+
+func TestDecodeRemoteWriteRequest_TruncatedVarint(t *testing.T) {
+	if _, err := decodeRemoteWriteRequest([]byte{0x80}); err == nil {
+		t.Fatal("expected an error for a truncated varint, got nil")
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// statusResponse is a plain operational snapshot, not the Prometheus-shaped
+// promResponse used by the query API — it has no established upstream
+// consumer to mirror, so it's shaped for a human (or a dashboard) reading it
+// directly.
+type statusResponse struct {
+	UptimeSeconds  float64        `json:"uptime_seconds"`
+	MetricCount    int            `json:"metric_count"`
+	TotalPoints    int            `json:"total_points"`
+	PointsByMetric map[string]int `json:"points_by_metric"`
+	EstimatedBytes int64          `json:"estimated_bytes"`
+}
+
+// handleStatus serves /api/v1/status: store cardinality, per-metric point
+// counts, a rough memory estimate, and process uptime. Finer-grained,
+// counter-style metrics (broadcast/drop/eviction rates) are exposed
+// separately via /debug/vars.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	counts := s.store.PointCounts()
+
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statusResponse{
+		UptimeSeconds:  time.Since(s.startedAt).Seconds(),
+		MetricCount:    len(counts),
+		TotalPoints:    total,
+		PointsByMetric: counts,
+		EstimatedBytes: s.store.EstimatedBytes(),
+	})
+}
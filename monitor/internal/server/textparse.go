@@ -0,0 +1,111 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ag-botkit/monitor/internal/storage"
+)
+
+// parseExposition parses a Prometheus/OpenMetrics text exposition payload
+// into MetricPoints. It covers the common subset emitted by exporters: an
+// optional `{label="value",...}` set and an optional explicit sample
+// timestamp in milliseconds, defaulting to now when the exporter omits it.
+// Comment lines (`# HELP`, `# TYPE`, ...) and blank lines are ignored.
+func parseExposition(r io.Reader) ([]storage.MetricPoint, error) {
+	var points []storage.MetricPoint
+	now := time.Now().UnixMilli()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, labels, rest, err := splitExpositionLine(line)
+		if err != nil {
+			continue
+		}
+
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+
+		ts := now
+		if len(fields) > 1 {
+			if ms, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+				ts = ms
+			}
+		}
+
+		points = append(points, storage.MetricPoint{
+			Timestamp:  ts,
+			MetricType: "gauge",
+			MetricName: name,
+			Value:      value,
+			Labels:     labels,
+		})
+	}
+
+	return points, scanner.Err()
+}
+
+// splitExpositionLine separates a `name{labels} value timestamp` line into
+// its metric name, label set, and the remaining "value timestamp" fields.
+func splitExpositionLine(line string) (name string, labels map[string]string, rest string, err error) {
+	braceIdx := strings.IndexByte(line, '{')
+	spaceIdx := strings.IndexByte(line, ' ')
+
+	if braceIdx == -1 || (spaceIdx != -1 && spaceIdx < braceIdx) {
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) != 2 {
+			return "", nil, "", fmt.Errorf("malformed exposition line: %q", line)
+		}
+		return parts[0], nil, parts[1], nil
+	}
+
+	closeIdx := strings.IndexByte(line[braceIdx:], '}')
+	if closeIdx == -1 {
+		return "", nil, "", fmt.Errorf("unterminated label set: %q", line)
+	}
+	closeIdx += braceIdx
+
+	name = line[:braceIdx]
+	labels = parseLabelSet(line[braceIdx+1 : closeIdx])
+	rest = strings.TrimSpace(line[closeIdx+1:])
+	return name, labels, rest, nil
+}
+
+// parseLabelSet parses the contents of a `{...}` label set, e.g.
+// `host="web1",region="us-east"`.
+func parseLabelSet(s string) map[string]string {
+	labels := make(map[string]string)
+	if s == "" {
+		return labels
+	}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(kv[0])
+		val := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		labels[key] = val
+	}
+	return labels
+}
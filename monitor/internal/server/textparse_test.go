@@ -0,0 +1,122 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitExpositionLine(t *testing.T) {
+	cases := []struct {
+		name       string
+		line       string
+		wantName   string
+		wantLabels map[string]string
+		wantRest   string
+		wantErr    bool
+	}{
+		{
+			name:     "no labels",
+			line:     "cpu_usage 42.5",
+			wantName: "cpu_usage",
+			wantRest: "42.5",
+		},
+		{
+			name:       "with labels",
+			line:       `cpu_usage{host="web1",region="us-east"} 42.5 1000`,
+			wantName:   "cpu_usage",
+			wantLabels: map[string]string{"host": "web1", "region": "us-east"},
+			wantRest:   "42.5 1000",
+		},
+		{
+			name:       "empty label set",
+			line:       `up{} 1`,
+			wantName:   "up",
+			wantLabels: map[string]string{},
+			wantRest:   "1",
+		},
+		{
+			name:    "malformed, no value",
+			line:    "cpu_usage",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated label set",
+			line:    `cpu_usage{host="web1" 42.5`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, labels, rest, err := splitExpositionLine(tc.line)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if name != tc.wantName {
+				t.Errorf("name = %q, want %q", name, tc.wantName)
+			}
+			if rest != tc.wantRest {
+				t.Errorf("rest = %q, want %q", rest, tc.wantRest)
+			}
+			if tc.wantLabels != nil {
+				if len(labels) != len(tc.wantLabels) {
+					t.Fatalf("labels = %v, want %v", labels, tc.wantLabels)
+				}
+				for k, v := range tc.wantLabels {
+					if labels[k] != v {
+						t.Errorf("labels[%q] = %q, want %q", k, labels[k], v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParseExposition(t *testing.T) {
+	body := strings.NewReader(`# HELP cpu_usage CPU usage
+# TYPE cpu_usage gauge
+cpu_usage{host="web1"} 10.5 1000
+
+up 1
+`)
+
+	points, err := parseExposition(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(points) != 2 {
+		t.Fatalf("expected 2 points, got %d", len(points))
+	}
+
+	if points[0].MetricName != "cpu_usage" || points[0].Value != 10.5 || points[0].Timestamp != 1000 {
+		t.Errorf("unexpected first point: %+v", points[0])
+	}
+	if points[0].Labels["host"] != "web1" {
+		t.Errorf("expected host=web1 label, got %v", points[0].Labels)
+	}
+
+	if points[1].MetricName != "up" || points[1].Value != 1 {
+		t.Errorf("unexpected second point: %+v", points[1])
+	}
+	if points[1].Timestamp == 0 {
+		t.Errorf("expected a defaulted timestamp, got 0")
+	}
+}
+
+func TestParseExposition_SkipsUnparseableLines(t *testing.T) {
+	body := strings.NewReader("cpu_usage not-a-number\nup 1\n")
+
+	points, err := parseExposition(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(points) != 1 || points[0].MetricName != "up" {
+		t.Fatalf("expected only the parseable line, got %+v", points)
+	}
+}
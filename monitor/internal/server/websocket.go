@@ -2,9 +2,11 @@ package server
 
 import (
 	"encoding/json"
+	"expvar"
 	"log"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ag-botkit/monitor/internal/storage"
@@ -19,32 +21,86 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+// Aggregator is implemented by internal/aggregator.Aggregator. It's declared
+// here, rather than importing that package, so the Hub stays usable without
+// an aggregator wired in.
+type Aggregator interface {
+	Observe(storage.MetricPoint)
+}
+
 // Hub maintains active WebSocket connections and broadcasts metrics
 type Hub struct {
-	store       *storage.MetricStore
-	clients     map[*Client]bool
-	broadcast   chan *storage.MetricPoint
-	register    chan *Client
-	unregister  chan *Client
-	mu          sync.RWMutex
+	store      *storage.MetricStore
+	clients    map[*Client]bool
+	broadcast  chan *storage.MetricPoint
+	register   chan *Client
+	unregister chan *Client
+	mu         sync.RWMutex
+
+	agg Aggregator
+
+	clientsConnected        expvar.Int
+	metricsBroadcast        expvar.Int
+	metricsDroppedBroadcast expvar.Int
+	metricsDroppedIngest    expvar.Int
+	clientsEvictedSlow      expvar.Int
 }
 
 // Client represents a WebSocket connection
 type Client struct {
-	hub  *Hub
-	conn *websocket.Conn
-	send chan []byte
+	hub    *Hub
+	conn   *websocket.Conn
+	send   chan []byte
+	filter *clientFilter
+
+	dropped    int64 // atomic
+	lastDropMs int64 // atomic, unix ms
+}
+
+// recordDrop marks one broadcast message as dropped for this client because
+// its send buffer was full.
+func (c *Client) recordDrop() {
+	atomic.AddInt64(&c.dropped, 1)
+	atomic.StoreInt64(&c.lastDropMs, time.Now().UnixMilli())
 }
 
+func (c *Client) droppedCount() int64 {
+	return atomic.LoadInt64(&c.dropped)
+}
+
+// lastDrop returns when the most recent message was dropped for this
+// client, or the zero Time if none has been.
+func (c *Client) lastDrop() time.Time {
+	ms := atomic.LoadInt64(&c.lastDropMs)
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// hubVarsOnce guards expvar.Publish, which panics on a duplicate name; tests
+// that construct multiple Hubs in one process would otherwise crash.
+var hubVarsOnce sync.Once
+
 // NewHub creates a new WebSocket hub
 func NewHub(store *storage.MetricStore) *Hub {
-	return &Hub{
+	h := &Hub{
 		store:      store,
 		clients:    make(map[*Client]bool),
 		broadcast:  make(chan *storage.MetricPoint, 256),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 	}
+
+	hubVarsOnce.Do(func() {
+		expvar.Publish("clients_connected", &h.clientsConnected)
+		expvar.Publish("metrics_broadcast", &h.metricsBroadcast)
+		expvar.Publish("metrics_dropped_broadcast", &h.metricsDroppedBroadcast)
+		expvar.Publish("metrics_dropped_ingest", &h.metricsDroppedIngest)
+		expvar.Publish("clients_evicted_slow", &h.clientsEvictedSlow)
+	})
+
+	return h
 }
 
 // Run starts the hub's main loop
@@ -55,49 +111,111 @@ func (h *Hub) Run() {
 			h.mu.Lock()
 			h.clients[client] = true
 			h.mu.Unlock()
+			h.clientsConnected.Add(1)
 			log.Printf("Dashboard client connected (total: %d)", len(h.clients))
 
 		case client := <-h.unregister:
-			h.mu.Lock()
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send)
-			}
-			h.mu.Unlock()
-			log.Printf("Dashboard client disconnected (total: %d)", len(h.clients))
+			h.disconnect(client, false)
 
 		case metric := <-h.broadcast:
-			// Broadcast to all dashboard clients
+			h.mu.RLock()
+			var interested []*Client
+			for client := range h.clients {
+				if client.filter == nil || client.filter.matches(metric) {
+					interested = append(interested, client)
+				}
+			}
+			h.mu.RUnlock()
+
+			if len(interested) == 0 {
+				continue
+			}
+
+			// Only pay for serialization once we know someone wants it.
 			data, err := json.Marshal(metric)
 			if err != nil {
 				log.Printf("Error marshaling metric: %v", err)
 				continue
 			}
+			h.metricsBroadcast.Add(1)
 
-			h.mu.RLock()
-			for client := range h.clients {
+			for _, client := range interested {
 				select {
 				case client.send <- data:
 				default:
-					// Client is slow, close it
-					close(client.send)
-					delete(h.clients, client)
+					client.recordDrop()
+					h.disconnect(client, true)
 				}
 			}
-			h.mu.RUnlock()
 		}
 	}
 }
 
+// disconnect removes a client under the write lock, so the clients map is
+// never mutated while only read-locked (as the broadcast loop's interest
+// scan does). slow distinguishes a backpressure eviction from a normal
+// readPump/writePump-driven disconnect, for logging and the
+// clients_evicted_slow counter.
+func (h *Hub) disconnect(c *Client, slow bool) {
+	h.mu.Lock()
+	_, ok := h.clients[c]
+	if ok {
+		delete(h.clients, c)
+		close(c.send)
+	}
+	remaining := len(h.clients)
+	h.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	h.clientsConnected.Add(-1)
+	if slow {
+		h.clientsEvictedSlow.Add(1)
+		log.Printf("Dashboard client evicted (slow consumer, dropped=%d, last_drop=%s, total: %d)",
+			c.droppedCount(), c.lastDrop().Format(time.RFC3339), remaining)
+	} else {
+		log.Printf("Dashboard client disconnected (total: %d)", remaining)
+	}
+}
+
 // BroadcastMetric sends a metric to all connected dashboard clients
 func (h *Hub) BroadcastMetric(metric *storage.MetricPoint) {
 	select {
 	case h.broadcast <- metric:
 	default:
+		h.metricsDroppedBroadcast.Add(1)
 		log.Printf("Warning: broadcast channel full, dropping metric")
 	}
 }
 
+// RecordIngestDrop counts one payload that was rejected on the way in (e.g. a
+// remote_write body that fails to decode, a scrape body that fails to read,
+// or a WebSocket message that isn't valid JSON), as opposed to
+// BroadcastMetric's fan-out drops. Individual unparseable scrape lines are
+// skipped by parseExposition without being counted here.
+func (h *Hub) RecordIngestDrop() {
+	h.metricsDroppedIngest.Add(1)
+}
+
+// Ingest stores a point and broadcasts it to dashboard clients. It is the
+// common entry point for every ingestion path (WebSocket, remote_write,
+// scrape) so they all feed the same store+broadcast pipeline.
+func (h *Hub) Ingest(point storage.MetricPoint) {
+	h.store.Append(point)
+	h.BroadcastMetric(&point)
+	if h.agg != nil {
+		h.agg.Observe(point)
+	}
+}
+
+// SetAggregator wires an Aggregator into the ingestion pipeline so every
+// ingested point also feeds its tumbling windows.
+func (h *Hub) SetAggregator(agg Aggregator) {
+	h.agg = agg
+}
+
 // HandleMetricsWS handles the /metrics WebSocket endpoint (ingestion)
 func (h *Hub) HandleMetricsWS(w http.ResponseWriter, r *http.Request) {
 	conn, err := upgrader.Upgrade(w, r, nil)
@@ -122,20 +240,25 @@ func (h *Hub) HandleMetricsWS(w http.ResponseWriter, r *http.Request) {
 		var metric storage.MetricPoint
 		if err := json.Unmarshal(message, &metric); err != nil {
 			log.Printf("Error parsing metric: %v (message: %s)", err, string(message))
+			h.RecordIngestDrop()
 			continue
 		}
 
-		// Store metric
-		h.store.Append(metric)
-
-		// Broadcast to dashboard clients
-		h.BroadcastMetric(&metric)
+		h.Ingest(metric)
 	}
 
 	log.Printf("Metrics client disconnected from %s", r.RemoteAddr)
 }
 
-// HandleDashboardWS handles the /dashboard WebSocket endpoint (broadcast)
+// defaultReplayWindow is how far back to replay history when the client
+// doesn't specify a `since` query param.
+const defaultReplayWindow = 60 * time.Second
+
+// HandleDashboardWS handles the /dashboard WebSocket endpoint (broadcast).
+// Query params narrow the subscription: `metric` is a glob matched against
+// MetricName (e.g. `cpu.*`), `labels` is a comma-separated list of
+// `key=value` equality constraints, and `since` (a Go duration, e.g. `5m`)
+// controls how much history is replayed on connect.
 func (h *Hub) HandleDashboardWS(w http.ResponseWriter, r *http.Request) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -143,10 +266,19 @@ func (h *Hub) HandleDashboardWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	filter := newClientFilter(r)
+	since := defaultReplayWindow
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil {
+			since = d
+		}
+	}
+
 	client := &Client{
-		hub:  h,
-		conn: conn,
-		send: make(chan []byte, 256),
+		hub:    h,
+		conn:   conn,
+		send:   make(chan []byte, 256),
+		filter: filter,
 	}
 
 	h.register <- client
@@ -155,13 +287,23 @@ func (h *Hub) HandleDashboardWS(w http.ResponseWriter, r *http.Request) {
 	go client.writePump()
 	go client.readPump()
 
-	// Send initial data (last 60 seconds)
+	// Replay matching history for the requested window.
 	go func() {
 		time.Sleep(100 * time.Millisecond)
-		recentMetrics := h.store.GetRecentMetrics(60000) // Last 60 seconds
 
-		for metricName, points := range recentMetrics {
-			for _, point := range points {
+		endMs := time.Now().UnixMilli()
+		startMs := endMs - since.Milliseconds()
+
+		for _, metricName := range h.store.GetAllMetrics() {
+			if !filter.matchesName(metricName) {
+				continue
+			}
+
+			for _, point := range h.store.GetRange(metricName, startMs, endMs) {
+				if !filter.matches(&point) {
+					continue
+				}
+
 				data, err := json.Marshal(point)
 				if err != nil {
 					continue
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ag-botkit/monitor/internal/storage"
+)
+
+// TestHub_EvictsSlowConsumer drives the backpressure path chunk0-6 fixed a
+// race in: a client whose send buffer never drains should be evicted (not
+// leaked, and not mutated from two goroutines at once) once the broadcast
+// loop finds it full.
+func TestHub_EvictsSlowConsumer(t *testing.T) {
+	store := storage.NewMetricStore(100)
+	h := NewHub(store)
+	go h.Run()
+
+	client := &Client{hub: h, send: make(chan []byte, 1)}
+	h.register <- client
+
+	// Give Run() a moment to process the registration before we start
+	// hammering the broadcast channel.
+	waitUntil(t, func() bool {
+		h.mu.RLock()
+		defer h.mu.RUnlock()
+		return h.clients[client]
+	})
+
+	// The client never reads from send, so its 1-slot buffer fills after the
+	// first broadcast and the next point trips the slow-path eviction; later
+	// points no longer reach the evicted client.
+	for i := 0; i < 50; i++ {
+		h.BroadcastMetric(&storage.MetricPoint{MetricName: "cpu.usage", Value: float64(i)})
+	}
+
+	waitUntil(t, func() bool {
+		h.mu.RLock()
+		defer h.mu.RUnlock()
+		return !h.clients[client]
+	})
+
+	closed := false
+	for !closed {
+		if _, ok := <-client.send; !ok {
+			closed = true
+		}
+	}
+	if client.droppedCount() == 0 {
+		t.Error("expected at least one recorded drop for the evicted client")
+	}
+	if client.lastDrop().IsZero() {
+		t.Error("expected lastDrop to be set for the evicted client")
+	}
+}
+
+// waitUntil polls cond until it's true or a short timeout elapses, to avoid
+// racing the Hub's own goroutine without sprinkling sleeps through the test.
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("condition not met before timeout")
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"log"
 	"sync"
 	"time"
 )
@@ -19,6 +20,7 @@ type MetricStore struct {
 	mu       sync.RWMutex
 	metrics  map[string]*RingBuffer // key: metric_name
 	capacity int
+	wal      *wal
 }
 
 // RingBuffer implements a fixed-size circular buffer for time-series data
@@ -87,7 +89,9 @@ func (rb *RingBuffer) GetRange(startMs, endMs int64) []MetricPoint {
 	return result
 }
 
-// NewMetricStore creates a new metric store
+// NewMetricStore creates a new in-memory metric store with no durability:
+// a restart loses everything in the ring buffers. Use NewMetricStoreWithWAL
+// for a store that survives restarts.
 func NewMetricStore(capacity int) *MetricStore {
 	return &MetricStore{
 		metrics:  make(map[string]*RingBuffer),
@@ -95,8 +99,52 @@ func NewMetricStore(capacity int) *MetricStore {
 	}
 }
 
-// Append adds a metric point to the store
+// NewMetricStoreWithWAL creates a metric store backed by a write-ahead log
+// under cfg.DataDir. On startup it replays segments within cfg.Retention to
+// rehydrate the ring buffers, then starts a background compactor that drops
+// segments older than the retention window. The WAL writer runs on its own
+// goroutine so Append never blocks on disk I/O; if it falls behind, new
+// records are dropped and counted rather than stalling ingestion.
+func NewMetricStoreWithWAL(capacity int, cfg WALConfig) (*MetricStore, error) {
+	ms := &MetricStore{
+		metrics:  make(map[string]*RingBuffer),
+		capacity: capacity,
+	}
+
+	w, replayed, err := openWALAndReplay(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ms.wal = w
+
+	for _, p := range replayed {
+		ms.appendLocal(p)
+	}
+	log.Printf("wal: replayed %d point(s) from %s", len(replayed), cfg.DataDir)
+
+	return ms, nil
+}
+
+// Close releases resources held by the store, including the WAL writer
+// goroutine and its open segment file. A no-op for stores without a WAL.
+func (ms *MetricStore) Close() error {
+	if ms.wal != nil {
+		return ms.wal.close()
+	}
+	return nil
+}
+
+// Append adds a metric point to the store, and to the WAL if one is configured.
 func (ms *MetricStore) Append(point MetricPoint) {
+	ms.appendLocal(point)
+	if ms.wal != nil {
+		ms.wal.append(point)
+	}
+}
+
+// appendLocal updates the in-memory ring buffers only, skipping the WAL.
+// Used both by Append and by WAL replay on startup.
+func (ms *MetricStore) appendLocal(point MetricPoint) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
@@ -146,6 +194,38 @@ func (ms *MetricStore) GetAllMetrics() []string {
 	return names
 }
 
+// PointCounts returns the number of currently buffered points for each
+// metric, keyed by metric name. Used by the status/introspection endpoint.
+func (ms *MetricStore) PointCounts() map[string]int {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	counts := make(map[string]int, len(ms.metrics))
+	for name, rb := range ms.metrics {
+		counts[name] = rb.size
+	}
+	return counts
+}
+
+// estimatedPointBytes is a rough per-point overhead estimate (timestamp,
+// type/name/label pointers, value, plus the label map itself) used for
+// EstimatedBytes. It's intentionally approximate; exact accounting would
+// require walking every label map.
+const estimatedPointBytes = 256
+
+// EstimatedBytes returns a rough estimate of the memory held by all buffered
+// points. This is a ballpark figure for operators, not an exact accounting.
+func (ms *MetricStore) EstimatedBytes() int64 {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var total int64
+	for _, rb := range ms.metrics {
+		total += int64(rb.size) * estimatedPointBytes
+	}
+	return total
+}
+
 // GetRecentMetrics returns recent points from all metrics (for dashboard)
 func (ms *MetricStore) GetRecentMetrics(durationMs int64) map[string][]MetricPoint {
 	ms.mu.RLock()
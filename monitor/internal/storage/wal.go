@@ -0,0 +1,330 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WALConfig enables optional durable persistence for a MetricStore.
+type WALConfig struct {
+	// DataDir holds WAL segment files. Required to enable the WAL.
+	DataDir string
+	// Retention is how long a segment is kept before the compactor removes it.
+	Retention time.Duration
+	// SegmentBytes is the approximate size at which a segment is rotated.
+	// Defaults to 8MB when zero.
+	SegmentBytes int64
+}
+
+const defaultSegmentBytes = 8 * 1024 * 1024
+
+const segPrefix = "seg-"
+const segSuffix = ".log"
+
+// segmentInfo is the in-memory index entry for one WAL segment file.
+type segmentInfo struct {
+	path    string
+	startMs int64
+	endMs   int64
+}
+
+// wal is an append-only, segmented write-ahead log backing a MetricStore.
+// Writes are buffered through a channel drained by a dedicated goroutine so
+// the hot Append path never blocks on disk I/O; if the writer falls behind,
+// new records are dropped and counted, matching the existing broadcast
+// channel's drop-with-counter behavior.
+type wal struct {
+	cfg WALConfig
+
+	writeCh chan MetricPoint
+	done    chan struct{}
+	closed  chan struct{}
+
+	mu       sync.Mutex
+	segments []*segmentInfo
+	cur      *os.File
+	curPath  string
+	curSize  int64
+
+	dropped int64
+}
+
+// openWALAndReplay opens (creating if necessary) the WAL under cfg.DataDir,
+// replays segments within cfg.Retention, and starts a fresh segment for new
+// writes. It returns the replayed points so the caller can rehydrate its
+// ring buffers.
+func openWALAndReplay(cfg WALConfig) (*wal, []MetricPoint, error) {
+	if cfg.SegmentBytes <= 0 {
+		cfg.SegmentBytes = defaultSegmentBytes
+	}
+	if err := os.MkdirAll(cfg.DataDir, 0o755); err != nil {
+		return nil, nil, fmt.Errorf("wal: create data dir: %w", err)
+	}
+
+	w := &wal{
+		cfg:     cfg,
+		writeCh: make(chan MetricPoint, 4096),
+		done:    make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+
+	cutoffMs := time.Now().Add(-cfg.Retention).UnixMilli()
+	replayed, err := w.loadAndReplay(cutoffMs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := w.rotate(time.Now().UnixMilli()); err != nil {
+		return nil, nil, err
+	}
+
+	go w.run()
+
+	return w, replayed, nil
+}
+
+// loadAndReplay scans existing segment files, builds the in-memory index,
+// and returns every point newer than cutoffMs.
+func (w *wal) loadAndReplay(cutoffMs int64) ([]MetricPoint, error) {
+	entries, err := os.ReadDir(w.cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("wal: read data dir: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), segPrefix) && strings.HasSuffix(e.Name(), segSuffix) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var replayed []MetricPoint
+	for _, name := range names {
+		path := filepath.Join(w.cfg.DataDir, name)
+
+		startMs, err := segmentStart(name)
+		if err != nil {
+			log.Printf("wal: skipping unparseable segment name %s: %v", name, err)
+			continue
+		}
+
+		points, maxTs, err := readSegment(path)
+		if err != nil {
+			log.Printf("wal: skipping unreadable segment %s: %v", path, err)
+			continue
+		}
+
+		if maxTs < startMs {
+			maxTs = startMs
+		}
+		w.segments = append(w.segments, &segmentInfo{path: path, startMs: startMs, endMs: maxTs})
+
+		for _, p := range points {
+			if p.Timestamp >= cutoffMs {
+				replayed = append(replayed, p)
+			}
+		}
+	}
+
+	return replayed, nil
+}
+
+// segmentStart extracts the start timestamp encoded in a segment filename.
+func segmentStart(name string) (int64, error) {
+	trimmed := strings.TrimSuffix(strings.TrimPrefix(name, segPrefix), segSuffix)
+	return strconv.ParseInt(trimmed, 10, 64)
+}
+
+// readSegment reads every length-prefixed JSON record in a segment file and
+// returns the decoded points plus the max timestamp observed.
+func readSegment(path string) ([]MetricPoint, int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var points []MetricPoint
+	var maxTs int64
+	offset := 0
+	for offset < len(data) {
+		if offset+4 > len(data) {
+			break // truncated trailing record; ignore it
+		}
+		recLen := int(binary.BigEndian.Uint32(data[offset : offset+4]))
+		offset += 4
+		if offset+recLen > len(data) {
+			break
+		}
+
+		var p MetricPoint
+		if err := json.Unmarshal(data[offset:offset+recLen], &p); err != nil {
+			offset += recLen
+			continue
+		}
+		offset += recLen
+
+		points = append(points, p)
+		if p.Timestamp > maxTs {
+			maxTs = p.Timestamp
+		}
+	}
+
+	return points, maxTs, nil
+}
+
+// rotate closes the current segment (if any) and opens a new one starting
+// at startMs.
+func (w *wal) rotate(startMs int64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cur != nil {
+		w.cur.Close()
+	}
+
+	path := filepath.Join(w.cfg.DataDir, fmt.Sprintf("%s%d%s", segPrefix, startMs, segSuffix))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("wal: open segment %s: %w", path, err)
+	}
+
+	w.cur = f
+	w.curPath = path
+	w.curSize = 0
+	w.segments = append(w.segments, &segmentInfo{path: path, startMs: startMs, endMs: startMs})
+
+	return nil
+}
+
+// append queues a point for durable persistence. Non-blocking: if the
+// writer goroutine is behind, the point is dropped and counted.
+func (w *wal) append(p MetricPoint) {
+	select {
+	case w.writeCh <- p:
+	default:
+		atomic.AddInt64(&w.dropped, 1)
+		log.Printf("wal: write channel full, dropping point for %s", p.MetricName)
+	}
+}
+
+// dropCount returns how many points have been dropped for WAL backpressure.
+func (w *wal) dropCount() int64 {
+	return atomic.LoadInt64(&w.dropped)
+}
+
+func (w *wal) run() {
+	compactTicker := time.NewTicker(time.Minute)
+	defer compactTicker.Stop()
+	defer close(w.closed)
+
+	for {
+		select {
+		case p, ok := <-w.writeCh:
+			if !ok {
+				return
+			}
+			w.writeRecord(p)
+		case <-compactTicker.C:
+			w.compact()
+		case <-w.done:
+			// Drain whatever's buffered before shutting down.
+			for {
+				select {
+				case p := <-w.writeCh:
+					w.writeRecord(p)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// writeRecord appends one length-prefixed JSON record to the current
+// segment, rotating first if it has grown past SegmentBytes.
+func (w *wal) writeRecord(p MetricPoint) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		log.Printf("wal: failed to marshal point for %s: %v", p.MetricName, err)
+		return
+	}
+
+	w.mu.Lock()
+	if w.curSize >= w.cfg.SegmentBytes {
+		w.mu.Unlock()
+		if err := w.rotate(p.Timestamp); err != nil {
+			log.Printf("wal: failed to rotate segment: %v", err)
+			return
+		}
+		w.mu.Lock()
+	}
+
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(data)))
+
+	if _, err := w.cur.Write(lenBuf[:]); err == nil {
+		_, err = w.cur.Write(data)
+	}
+	if err != nil {
+		log.Printf("wal: write failed: %v", err)
+		w.mu.Unlock()
+		return
+	}
+
+	w.curSize += int64(len(lenBuf) + len(data))
+	if len(w.segments) > 0 {
+		last := w.segments[len(w.segments)-1]
+		if p.Timestamp > last.endMs {
+			last.endMs = p.Timestamp
+		}
+	}
+	w.mu.Unlock()
+}
+
+// compact removes segments whose data has fully aged out of the retention
+// window. The currently-open segment is never removed.
+func (w *wal) compact() {
+	cutoff := time.Now().Add(-w.cfg.Retention).UnixMilli()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	kept := w.segments[:0]
+	for _, s := range w.segments {
+		if s.path == w.curPath || s.endMs >= cutoff {
+			kept = append(kept, s)
+			continue
+		}
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			log.Printf("wal: failed to remove expired segment %s: %v", s.path, err)
+			kept = append(kept, s)
+			continue
+		}
+	}
+	w.segments = kept
+}
+
+// close stops the writer goroutine, flushing any buffered records first, and
+// closes the current segment file.
+func (w *wal) close() error {
+	close(w.done)
+	<-w.closed
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cur != nil {
+		return w.cur.Close()
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWAL_PersistsAndReplays(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewMetricStoreWithWAL(100, WALConfig{DataDir: dir, Retention: time.Hour})
+	if err != nil {
+		t.Fatalf("NewMetricStoreWithWAL failed: %v", err)
+	}
+
+	store.Append(MetricPoint{Timestamp: time.Now().UnixMilli(), MetricName: "cpu.usage", Value: 42})
+
+	// Give the async WAL writer a moment to flush the record to disk.
+	waitForWAL(t, store)
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := NewMetricStoreWithWAL(100, WALConfig{DataDir: dir, Retention: time.Hour})
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+
+	points := reopened.GetLast("cpu.usage", 1)
+	if len(points) != 1 {
+		t.Fatalf("expected 1 replayed point, got %d", len(points))
+	}
+	if points[0].Value != 42 {
+		t.Errorf("expected value 42, got %f", points[0].Value)
+	}
+}
+
+func TestWAL_CompactsExpiredSegments(t *testing.T) {
+	dir := t.TempDir()
+
+	// A segment left over from an earlier run, old enough to be expired.
+	expired := filepath.Join(dir, fmt.Sprintf("%s%d%s", segPrefix, time.Now().Add(-2*time.Hour).UnixMilli(), segSuffix))
+	if err := os.WriteFile(expired, nil, 0o644); err != nil {
+		t.Fatalf("failed to create expired segment: %v", err)
+	}
+
+	store, err := NewMetricStoreWithWAL(100, WALConfig{DataDir: dir, Retention: 0})
+	if err != nil {
+		t.Fatalf("NewMetricStoreWithWAL failed: %v", err)
+	}
+
+	old := MetricPoint{Timestamp: time.Now().Add(-time.Hour).UnixMilli(), MetricName: "old.metric", Value: 1}
+	store.Append(old)
+	waitForWAL(t, store)
+
+	store.wal.compact()
+
+	store.wal.mu.Lock()
+	remaining := len(store.wal.segments)
+	store.wal.mu.Unlock()
+
+	if remaining != 1 {
+		t.Errorf("expected only the current segment to remain, got %d segments", remaining)
+	}
+	if _, err := os.Stat(expired); !os.IsNotExist(err) {
+		t.Errorf("expected expired segment file to be removed, stat err = %v", err)
+	}
+
+	store.Close()
+}
+
+// waitForWAL polls until the store's WAL has drained its write channel, to
+// avoid racing the async writer goroutine in tests.
+func waitForWAL(t *testing.T, store *MetricStore) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if len(store.wal.writeCh) == 0 {
+			time.Sleep(20 * time.Millisecond) // let the writer finish its current record
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("timed out waiting for WAL to drain")
+}